@@ -25,9 +25,15 @@ func GetReportHandler(cfg *types.Config) func(c *gin.Context) {
 			return
 		}
 
-		date, err := time.Parse(time.RFC3339, c.Query(dateParam))
+		location, err := cfg.Report.GetLocation()
 		if err != nil {
-			c.String(http.StatusBadRequest, "Invalid date. Must be in RFC3339 format")
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		date, err := types.ParseDate(c.Query(dateParam), location)
+		if err != nil {
+			c.String(http.StatusBadRequest, err.Error())
 			return
 		}
 
@@ -10,8 +10,9 @@ import (
 )
 
 const (
-	idParam     = "id"
-	outputParam = "output"
+	idParam      = "id"
+	outputParam  = "output"
+	groupByParam = "group_by"
 )
 
 // GetResultHandler returns the handler used to get the results of a report
@@ -43,7 +44,23 @@ func GetResultHandler() func(c *gin.Context) {
 			c.String(http.StatusBadRequest, err.Error())
 		}
 
-		bz, err := report.MarshalAmounts(result.GetAmounts(), output)
+		if output.IsRW() {
+			c.String(http.StatusBadRequest, "The Quadro RW output is only supported by the report command")
+			return
+		}
+
+		groupByValue := c.Query(groupByParam)
+		if groupByValue == "" {
+			groupByValue = types.GroupByAsset.String()
+		}
+
+		groupBy, err := types.ParseGroupBy(groupByValue)
+		if err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		bz, err := report.MarshalAmounts(result.GetAmounts(groupBy), result.Metadata, output)
 		if err != nil {
 			c.String(http.StatusInternalServerError, err.Error())
 			return
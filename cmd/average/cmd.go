@@ -0,0 +1,114 @@
+package average
+
+import (
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/riccardom/briatore/report"
+	"github.com/riccardom/briatore/types"
+)
+
+const (
+	flagFile     = "file"
+	flagOutput   = "output"
+	flagInterval = "interval"
+)
+
+// GetAverageCmd returns the command to compute the average holdings over a period
+func GetAverageCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "average [from] [to] [addresses]",
+		Short: "Computes the average holdings of the provided addresses between the given dates",
+		Long: `Computes the average holdings (giacenza media) of the provided addresses between the given dates.
+The holdings are sampled at each interval starting from the first date and up to the last one, both included.
+Dates are parsed as in the report command, so dates without a time are resolved to the end of the day.
+The output contains the average amount and value of each asset, their peak, and whether the configured thresholds
+have been exceeded.
+The provided addresses must be comma separated.`,
+		Example: "average 2023-01-01 2023-12-31 cosmos1...,juno1....",
+		Args:    cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SetOut(os.Stdout)
+
+			cfg, err := types.ReadConfig(cmd)
+			if err != nil {
+				return err
+			}
+
+			location, err := cfg.Report.GetLocation()
+			if err != nil {
+				return err
+			}
+
+			from, err := types.ParseDate(args[0], location)
+			if err != nil {
+				return err
+			}
+
+			to, err := types.ParseDate(args[1], location)
+			if err != nil {
+				return err
+			}
+
+			addresses := strings.Split(args[2], ",")
+
+			outValue, err := cmd.Flags().GetString(flagOutput)
+			if err != nil {
+				return err
+			}
+
+			out, err := types.ParseOutput(outValue)
+			if err != nil {
+				return err
+			}
+
+			interval := cfg.Report.GetAverageConfig().Interval
+			if cmd.Flags().Changed(flagInterval) {
+				interval, err = cmd.Flags().GetDuration(flagInterval)
+				if err != nil {
+					return err
+				}
+			}
+
+			averageReport, err := report.GetAverageReport(cfg, addresses, from, to, interval)
+			if err != nil {
+				return err
+			}
+
+			for _, warning := range averageReport.Warnings {
+				log.Warn().Msg(warning)
+			}
+
+			for _, threshold := range averageReport.Thresholds {
+				if threshold.Exceeded {
+					log.Warn().Str("threshold", threshold.Name).Str("measure", string(threshold.Measure)).
+						Msgf("threshold exceeded: %s > %s", threshold.Value, threshold.Threshold)
+				}
+			}
+
+			bz, err := report.MarshalAverage(averageReport, out)
+			if err != nil {
+				return err
+			}
+
+			outputFile, _ := cmd.Flags().GetString(flagFile)
+			if outputFile != "" {
+				log.Info().Msg("writing average report to file")
+				return os.WriteFile(outputFile, bz, 0666)
+			}
+
+			cmd.Print(string(bz))
+
+			return nil
+		},
+	}
+
+	cmd.Flags().String(flagFile, "", "File where to store the average report")
+	cmd.Flags().String(flagOutput, types.OutText.String(), "Type of output (supported values: json, text, csv)")
+	cmd.Flags().Duration(flagInterval, types.Day, "Time between two consecutive samples, overriding the config (e.g. 24h)")
+
+	return cmd
+}
@@ -5,12 +5,18 @@ import (
 	"os"
 	"path"
 
+	// Embed the timezone database so that the configured timezone can be loaded on systems that do not have it
+	_ "time/tzdata"
+
 	"github.com/cometbft/cometbft/libs/cli"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/spf13/cobra"
 
+	averagecmd "github.com/riccardom/briatore/cmd/average"
+	fxcmd "github.com/riccardom/briatore/cmd/fx"
+	pricescmd "github.com/riccardom/briatore/cmd/prices"
 	reportcmd "github.com/riccardom/briatore/cmd/report"
 	startcmd "github.com/riccardom/briatore/cmd/start"
 	"github.com/riccardom/briatore/utils"
@@ -27,6 +33,9 @@ func main() {
 	}
 	rootCmd.AddCommand(
 		reportcmd.GetReportCmd(),
+		averagecmd.GetAverageCmd(),
+		pricescmd.GetPricesCmd(),
+		fxcmd.GetFXCmd(),
 		startcmd.GetStartCmd(),
 	)
 
@@ -0,0 +1,96 @@
+package fx
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/riccardom/briatore/types"
+)
+
+const (
+	flagSource = "source"
+)
+
+// GetFXCmd returns the command used to manage the exchange rates
+func GetFXCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fx",
+		Short: "Manage the exchange rates used to convert the values into the report currency",
+	}
+
+	cmd.AddCommand(
+		GetImportCmd(),
+	)
+
+	return cmd
+}
+
+// GetImportCmd returns the command used to import the exchange rates from a CSV file
+func GetImportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import [file]",
+		Short: "Imports the exchange rates from the given CSV file",
+		Long: `Imports the official exchange rates contained inside the given CSV file into the cache.
+Supported sources:
+- ecb: the euro foreign exchange reference rates published by the ECB (e.g. eurofxref-hist.csv)
+- bancaditalia: the daily exchange rates published by Banca d'Italia
+Importing a rate for the same currencies, date and source of an already imported one replaces it.`,
+		Example: "fx import eurofxref-hist.csv --source ecb",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sourceValue, err := cmd.Flags().GetString(flagSource)
+			if err != nil {
+				return err
+			}
+
+			source, err := parseSource(sourceValue)
+			if err != nil {
+				return err
+			}
+
+			// Read the config so that the home folder is set up properly
+			_, err = types.ReadConfig(cmd)
+			if err != nil {
+				return err
+			}
+
+			bz, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("error while reading exchange rates file: %w", err)
+			}
+
+			rates, err := types.ParseExchangeRates(bz, source)
+			if err != nil {
+				return fmt.Errorf("error while parsing exchange rates file: %w", err)
+			}
+
+			err = types.CacheExchangeRates(rates)
+			if err != nil {
+				return err
+			}
+
+			log.Info().Int("rates", len(rates)).Str("source", source).Msg("exchange rates imported")
+			return nil
+		},
+	}
+
+	cmd.Flags().String(flagSource, "ecb", "Source of the exchange rates file (supported values: ecb, bancaditalia)")
+
+	return cmd
+}
+
+// parseSource returns the exchange rates source identified by the given flag value
+func parseSource(value string) (string, error) {
+	switch strings.ToLower(value) {
+	case "ecb":
+		return types.ExchangeRatesSourceECB, nil
+	case "bancaditalia":
+		return types.ExchangeRatesSourceBancaDItalia, nil
+	default:
+		return "", fmt.Errorf("invalid exchange rates source: %s", value)
+	}
+}
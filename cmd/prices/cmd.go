@@ -0,0 +1,79 @@
+package prices
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gocarina/gocsv"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/riccardom/briatore/types"
+)
+
+// GetPricesCmd returns the command used to manage the prices
+func GetPricesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prices",
+		Short: "Manage the prices used inside the reports",
+	}
+
+	cmd.AddCommand(
+		GetImportCmd(),
+	)
+
+	return cmd
+}
+
+// GetImportCmd returns the command used to import manual prices from a CSV file
+func GetImportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import [file]",
+		Short: "Imports manual prices from the given CSV file",
+		Long: `Imports the prices contained inside the given CSV file into the price cache, marking them as manual.
+Manual prices always take precedence over the ones read from the price providers.
+The file must have the following columns: denom, date, currency, price, source.
+The currency defaults to the report one, and dates without a timezone are read inside the timezone set in the config.
+Importing a price for the same denom, currency and day of an already imported one replaces it.`,
+		Example: "prices import prices.csv",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := types.ReadConfig(cmd)
+			if err != nil {
+				return err
+			}
+
+			location, err := cfg.Report.GetLocation()
+			if err != nil {
+				return err
+			}
+
+			bz, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("error while reading prices file: %w", err)
+			}
+
+			var manualPrices []*types.ManualPriceConfig
+			err = gocsv.UnmarshalBytes(bz, &manualPrices)
+			if err != nil {
+				return fmt.Errorf("error while parsing prices file: %w", err)
+			}
+
+			pricesData := make([]types.PriceData, len(manualPrices))
+			for i, manualPrice := range manualPrices {
+				pricesData[i], err = manualPrice.GetPriceData(location, cfg.Report.Currency)
+				if err != nil {
+					return fmt.Errorf("invalid price at row %d: %w", i+1, err)
+				}
+			}
+
+			err = types.CacheManualPrices(pricesData, location)
+			if err != nil {
+				return err
+			}
+
+			log.Info().Int("prices", len(pricesData)).Msg("manual prices imported")
+			return nil
+		},
+	}
+}
@@ -1,11 +1,12 @@
 package report
 
 import (
+	"fmt"
 	"os"
 	"strings"
-	"time"
 
 	"github.com/riccardom/briatore/report"
+	"github.com/riccardom/briatore/tax"
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -14,8 +15,11 @@ import (
 )
 
 const (
-	flagFile   = "file"
-	flagOutput = "output"
+	flagFile        = "file"
+	flagOutput      = "output"
+	flagGroupBy     = "group-by"
+	flagBlockPolicy = "block-policy"
+	flagTax         = "tax"
 )
 
 // GetReportCmd returns the command to crete a report for a specific date
@@ -24,8 +28,11 @@ func GetReportCmd() *cobra.Command {
 		Use:   "report [date] [addresses]",
 		Short: "Reports the data for the given date and provided addresses",
 		Long: `Creates a report for the provided date and the given addresses.
-The provided addresses must be comma separated.`,
-		Example: "report 2021-12-31T23:59:59Z cosmos1...,juno1....",
+The date can be an RFC3339 timestamp, a date (resolved to the end of the day) or a year (resolved to the end of the year).
+Dates without a timezone are read inside the timezone set in the config.
+The provided addresses must be comma separated.
+The rw and rw-csv outputs compute the Quadro RW of the year of the date, using the reports of the first and last day of such year.`,
+		Example: "report 2021-12-31 cosmos1...,juno1....",
 		Args:    cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cmd.SetOut(os.Stdout)
@@ -35,7 +42,12 @@ The provided addresses must be comma separated.`,
 				return err
 			}
 
-			date, err := time.Parse(time.RFC3339, args[0])
+			location, err := cfg.Report.GetLocation()
+			if err != nil {
+				return err
+			}
+
+			date, err := types.ParseDate(args[0], location)
 			if err != nil {
 				return err
 			}
@@ -47,35 +59,117 @@ The provided addresses must be comma separated.`,
 				return err
 			}
 
-			result := report.GetReport(cfg, addresses, date)
-			if result.IsError() {
-				return result.Err()
+			out, err := types.ParseOutput(outValue)
+			if err != nil {
+				return err
 			}
 
-			out, err := types.ParseOutput(outValue)
+			groupByValue, err := cmd.Flags().GetString(flagGroupBy)
 			if err != nil {
 				return err
 			}
 
-			bz, err := report.MarshalAmounts(result.GetAmounts(), out)
+			groupBy, err := types.ParseGroupBy(groupByValue)
 			if err != nil {
 				return err
 			}
 
-			outputFile, _ := cmd.Flags().GetString(flagFile)
-			if outputFile != "" {
-				log.Info().Msg("writing reports to file")
-				return os.WriteFile(outputFile, bz, 0666)
+			blockPolicyValue, err := cmd.Flags().GetString(flagBlockPolicy)
+			if err != nil {
+				return err
+			}
+
+			if blockPolicyValue != "" {
+				cfg.Report.BlockPolicy, err = types.ParseBlockPolicy(blockPolicyValue)
+				if err != nil {
+					return err
+				}
+			}
+
+			taxValue, err := cmd.Flags().GetString(flagTax)
+			if err != nil {
+				return err
+			}
+
+			var reportTax types.Tax
+			if taxValue != "" {
+				reportTax, err = types.ParseTax(taxValue)
+				if err != nil {
+					return err
+				}
+			}
+
+			if out.IsRW() {
+				if taxValue != "" {
+					return fmt.Errorf("the %s flag cannot be used with the %s output", flagTax, out)
+				}
+
+				rwReport, err := report.GetRWReport(cfg, addresses, date.Year())
+				if err != nil {
+					return err
+				}
+
+				for _, warning := range rwReport.Warnings {
+					log.Warn().Msg(warning)
+				}
+
+				bz, err := report.MarshalRW(rwReport, out)
+				if err != nil {
+					return err
+				}
+
+				return writeOutput(cmd, bz)
+			}
+
+			result := report.GetReport(cfg, addresses, date)
+			if result.IsError() {
+				return result.Err()
+			}
+
+			if taxValue != "" {
+				// The tax is due on the whole holdings, so it cannot be computed if any chain is missing
+				err = result.Metadata.ValidateComplete()
+				if err != nil {
+					return fmt.Errorf("error while computing the tax: %w", err)
+				}
+
+				result.Tax, err = tax.ComputeTax(reportTax, cfg.Report, result.Amounts, date)
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, warning := range result.Metadata.Warnings {
+				log.Warn().Msg(warning)
 			}
 
-			cmd.Print(string(bz))
+			bz, err := report.MarshalReport(result.GetAmounts(groupBy), result.Tax, result.Metadata, out)
+			if err != nil {
+				return err
+			}
 
-			return nil
+			return writeOutput(cmd, bz)
 		},
 	}
 
 	cmd.Flags().String(flagFile, "", "File where to store the reports")
-	cmd.Flags().String(flagOutput, types.OutText.String(), "Type of output (supported values: json, text)")
+	cmd.Flags().String(flagOutput, types.OutText.String(), "Type of output (supported values: json, text, csv, rw, rw-csv)")
+	cmd.Flags().String(flagGroupBy, types.GroupByAsset.String(), "How to group the amounts (supported values: asset, chain, address)")
+	cmd.Flags().String(flagBlockPolicy, "", "How to choose the block of each chain, overriding the config (supported values: last-before, first-after, nearest)")
+	cmd.Flags().String(flagTax, "", "Tax to be computed on the reported values (supported values: ivca)")
 
 	return cmd
 }
+
+// writeOutput writes the given bytes to the output file, if set, or prints them otherwise
+func writeOutput(cmd *cobra.Command, bz []byte) error {
+	outputFile, _ := cmd.Flags().GetString(flagFile)
+	if outputFile != "" {
+		log.Info().Msg("writing reports to file")
+		return os.WriteFile(outputFile, bz, 0666)
+	}
+
+	cmd.Print(string(bz))
+
+	return nil
+}
@@ -44,6 +44,11 @@ func NewClient(rpcAddress string) (*Client, error) {
 	}, nil
 }
 
+// Stop stops the client, closing its websocket connection
+func (cp *Client) Stop() error {
+	return cp.client.Stop()
+}
+
 // MinHeight returns the minimum height of the chain
 func (cp *Client) MinHeight() (int64, error) {
 	res, err := cp.client.Status(cp.ctx)
@@ -64,11 +69,17 @@ func (cp *Client) LatestHeight() (int64, error) {
 	return height, nil
 }
 
-// Block returns the block having the given height
-func (cp *Client) Block(height int64) (*tmtypes.Block, error) {
-	block, err := cp.client.Block(cp.ctx, &height)
+// Header returns the header of the block having the given height.
+// The /blockchain endpoint is used instead of /header so that older nodes are supported too.
+func (cp *Client) Header(height int64) (*tmtypes.Header, error) {
+	res, err := cp.client.BlockchainInfo(cp.ctx, height, height)
 	if err != nil {
 		return nil, err
 	}
-	return block.Block, nil
+
+	if len(res.BlockMetas) == 0 {
+		return nil, fmt.Errorf("block not found at height %d", height)
+	}
+
+	return &res.BlockMetas[0].Header, nil
 }
@@ -0,0 +1,146 @@
+package prices
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/riccardom/briatore/types"
+)
+
+const (
+	CoinGeckoProviderName = "coingecko"
+
+	coinGeckoPublicURL  = "https://api.coingecko.com/api/v3"
+	coinGeckoProURL     = "https://pro-api.coingecko.com/api/v3"
+	coinGeckoHistoryAPI = "/coins/{id}/history?date={date}"
+	coinGeckoRangeAPI   = "/coins/{id}/market_chart/range?vs_currency={currency}&from={from}&to={to}"
+
+	// coinGeckoRangeWindow is the time before and after the requested timestamp for which the market chart is read.
+	// Ranges of one day return hourly data points
+	coinGeckoRangeWindow = 12 * time.Hour
+)
+
+var (
+	_ PriceProvider = &CoinGeckoProvider{}
+)
+
+// CoinGeckoProvider allows to get the historical prices of the assets from CoinGecko
+type CoinGeckoProvider struct {
+	mode    types.CoinGeckoMode
+	baseURL string
+	client  *HTTPClient
+}
+
+func NewCoinGeckoProvider(cfg *types.CoinGeckoConfig) (*CoinGeckoProvider, error) {
+	baseURL := coinGeckoPublicURL
+	headers := map[string]string{}
+
+	switch plan := cfg.GetPlan(); plan {
+	case types.CoinGeckoPlanPublic:
+	case types.CoinGeckoPlanDemo:
+		headers["x-cg-demo-api-key"] = cfg.APIKey
+	case types.CoinGeckoPlanPro:
+		baseURL = coinGeckoProURL
+		headers["x-cg-pro-api-key"] = cfg.APIKey
+	default:
+		return nil, fmt.Errorf("invalid CoinGecko plan: %s", plan)
+	}
+
+	mode := cfg.GetMode()
+	if mode != types.CoinGeckoModeDaily && mode != types.CoinGeckoModeExact {
+		return nil, fmt.Errorf("invalid CoinGecko mode: %s", mode)
+	}
+
+	return &CoinGeckoProvider{
+		mode:    mode,
+		baseURL: baseURL,
+		client:  NewHTTPClient(CoinGeckoProviderName, cfg.GetRequestsPerMinute(), cfg.GetTimeout(), headers),
+	}, nil
+}
+
+// Name implements PriceProvider
+func (p *CoinGeckoProvider) Name() string {
+	return CoinGeckoProviderName
+}
+
+// GetPrice implements PriceProvider
+func (p *CoinGeckoProvider) GetPrice(asset *types.Asset, timestamp time.Time, currency string) (Price, bool, error) {
+	if asset.CoingeckoID == "" {
+		return Price{}, false, nil
+	}
+
+	if p.mode == types.CoinGeckoModeExact {
+		return p.getRangePriceFromAPI(asset.CoingeckoID, timestamp, currency)
+	}
+
+	return p.getPriceFromAPI(asset.CoingeckoID, timestamp, currency)
+}
+
+// getPriceFromAPI returns the daily price for the coin having the given id for the given timestamp and currency.
+// The returned price refers to 00:00 UTC of the UTC date of the timestamp. If no market data is available for such
+// date, found is false.
+func (p *CoinGeckoProvider) getPriceFromAPI(id string, timestamp time.Time, currency string) (Price, bool, error) {
+	log.Debug().Str("id", id).Time("timestamp", timestamp).Msg("getting price from API")
+
+	// Daily prices are published for UTC dates
+	timestamp = timestamp.UTC()
+
+	endpoint := strings.ReplaceAll(p.baseURL+coinGeckoHistoryAPI, "{id}", id)
+	endpoint = strings.ReplaceAll(endpoint, "{date}", timestamp.Format("02-01-2006"))
+
+	bz, err := p.client.Get(endpoint)
+	if err != nil {
+		return Price{}, false, fmt.Errorf("error while getting token price history: %w", err)
+	}
+
+	var response types.HistoryResponse
+	err = json.Unmarshal(bz, &response)
+	if err != nil {
+		return Price{}, false, err
+	}
+
+	price, found, err := response.GetCoinPrice(currency)
+	if err != nil || !found {
+		return Price{}, false, err
+	}
+
+	year, month, day := timestamp.Date()
+	return NewPrice(price, time.Date(year, month, day, 0, 0, 0, 0, time.UTC)), true, nil
+}
+
+// getRangePriceFromAPI returns the price for the coin having the given id that is closest to the given timestamp,
+// reading the market chart around it. If no data point is present, found is false.
+func (p *CoinGeckoProvider) getRangePriceFromAPI(id string, timestamp time.Time, currency string) (Price, bool, error) {
+	log.Debug().Str("id", id).Time("timestamp", timestamp).Msg("getting price range from API")
+
+	endpoint := strings.ReplaceAll(p.baseURL+coinGeckoRangeAPI, "{id}", id)
+	endpoint = strings.ReplaceAll(endpoint, "{currency}", currency)
+	endpoint = strings.ReplaceAll(endpoint, "{from}", strconv.FormatInt(timestamp.Add(-coinGeckoRangeWindow).Unix(), 10))
+	endpoint = strings.ReplaceAll(endpoint, "{to}", strconv.FormatInt(timestamp.Add(coinGeckoRangeWindow).Unix(), 10))
+
+	bz, err := p.client.Get(endpoint)
+	if err != nil {
+		return Price{}, false, fmt.Errorf("error while getting token market chart: %w", err)
+	}
+
+	var response types.MarketChartResponse
+	err = json.Unmarshal(bz, &response)
+	if err != nil {
+		return Price{}, false, err
+	}
+
+	price, priceTimestamp, found := response.GetClosestPrice(timestamp)
+	if !found {
+		return Price{}, false, nil
+	}
+
+	log.Debug().Str("id", id).Time("timestamp", timestamp).Time("price timestamp", priceTimestamp).
+		Msg("found closest price")
+
+	return NewPrice(price, priceTimestamp), true, nil
+}
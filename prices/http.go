@@ -0,0 +1,152 @@
+package prices
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
+)
+
+const (
+	maxRetries     = 5
+	initialBackoff = 2 * time.Second
+	maxBackoff     = time.Minute
+
+	// minLoggedWait and minInfoWait are the waits for the rate limit above which the delay of a request
+	// is logged, respectively as debug and info
+	minLoggedWait = 10 * time.Millisecond
+	minInfoWait   = time.Second
+)
+
+// HTTPClient is an HTTP client that limits the rate of the requests sent to a price API,
+// retrying with an exponential backoff the ones that fail due to rate limits or server errors
+type HTTPClient struct {
+	name    string
+	client  *http.Client
+	limiter *rate.Limiter
+	headers map[string]string
+}
+
+// NewHTTPClient returns a new HTTPClient instance that sends at most the given number of requests per minute,
+// each one with the provided timeout and headers
+func NewHTTPClient(name string, requestsPerMinute int, timeout time.Duration, headers map[string]string) *HTTPClient {
+	return &HTTPClient{
+		name:    name,
+		client:  &http.Client{Timeout: timeout},
+		limiter: rate.NewLimiter(rate.Every(time.Minute/time.Duration(requestsPerMinute)), 1),
+		headers: headers,
+	}
+}
+
+// Get performs a GET request to the given endpoint, returning the response body
+func (c *HTTPClient) Get(endpoint string) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		err := c.wait()
+		if err != nil {
+			return nil, err
+		}
+
+		bz, retryAfter, err := c.get(endpoint)
+		if err == nil {
+			return bz, nil
+		}
+
+		if retryAfter < 0 || attempt >= maxRetries {
+			return nil, err
+		}
+
+		wait := retryAfter
+		if wait == 0 {
+			wait = getBackoff(attempt)
+		}
+
+		log.Warn().Str("api", c.name).Int("attempt", attempt+1).Dur("wait", wait).Err(err).
+			Msg("request failed, retrying")
+		time.Sleep(wait)
+	}
+}
+
+// wait blocks until the rate limit allows to perform a new request, logging the time spent waiting if any
+func (c *HTTPClient) wait() error {
+	start := time.Now()
+	err := c.limiter.Wait(context.Background())
+	if err != nil {
+		return err
+	}
+
+	waited := time.Since(start)
+	switch {
+	case waited >= minInfoWait:
+		log.Info().Str("api", c.name).Dur("wait", waited).Msg("request delayed by the rate limit")
+	case waited >= minLoggedWait:
+		log.Debug().Str("api", c.name).Dur("wait", waited).Msg("request delayed by the rate limit")
+	}
+
+	return nil
+}
+
+// get performs a single GET request to the given endpoint.
+// In case of error, it also returns the time to wait before retrying, which is 0 if the server did not specify it,
+// or a negative value if the request should not be retried.
+func (c *HTTPClient) get(endpoint string) (bz []byte, retryAfter time.Duration, err error) {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		// Network errors and timeouts can be retried
+		return nil, 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError {
+		return nil, parseRetryAfter(res.Header.Get("Retry-After")), fmt.Errorf("bad response: status %d", res.StatusCode)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, -1, fmt.Errorf("bad response: status %d", res.StatusCode)
+	}
+
+	bz, err = io.ReadAll(res.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return bz, 0, nil
+}
+
+// getBackoff returns the time to wait before performing the given retry attempt
+func getBackoff(attempt int) time.Duration {
+	backoff := time.Duration(float64(initialBackoff) * math.Pow(2, float64(attempt)))
+	return min(backoff, maxBackoff)
+}
+
+// parseRetryAfter parses the given Retry-After header value, which can either be a number of seconds or a date.
+// If the value is empty or invalid, 0 is returned.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+
+	return 0
+}
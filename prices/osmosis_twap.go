@@ -0,0 +1,225 @@
+package prices
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/riccardom/briatore/reporter"
+	"github.com/riccardom/briatore/reporter/osmosis"
+	"github.com/riccardom/briatore/types"
+)
+
+const (
+	OsmosisTwapProviderName = "osmosis-twap"
+)
+
+var (
+	_ PriceProvider = &OsmosisTwapProvider{}
+)
+
+// OsmosisTwapProvider allows to get the prices of the assets using the TWAP of the Osmosis pools
+// at the block near the requested time. Prices are measured against a quote asset,
+// and then converted to fiat using the price of such asset.
+type OsmosisTwapProvider struct {
+	cfg     *types.Config
+	twapCfg *types.OsmosisTwapConfig
+	cdc     codec.Codec
+
+	// quotePrices is used to get the fiat price of the quote asset
+	quotePrices *Chain
+
+	// reporters caches the reporters of the Osmosis eras, so that each of them is created only once
+	reporters *reporter.Reporters
+
+	// pools caches the ids of the pools found on chain for each denom, guarded by mutex since prices are
+	// fetched concurrently
+	mutex sync.Mutex
+	pools map[string]uint64
+}
+
+func NewOsmosisTwapProvider(cfg *types.Config, cdc codec.Codec, quotePrices *Chain) *OsmosisTwapProvider {
+	return &OsmosisTwapProvider{
+		cfg:         cfg,
+		twapCfg:     cfg.Report.GetOsmosisTwapConfig(),
+		cdc:         cdc,
+		quotePrices: quotePrices,
+		reporters:   reporter.NewReporters(cdc, quotePrices),
+		pools:       map[string]uint64{},
+	}
+}
+
+// Name implements PriceProvider
+func (p *OsmosisTwapProvider) Name() string {
+	return OsmosisTwapProviderName
+}
+
+// GetPrice implements PriceProvider
+func (p *OsmosisTwapProvider) GetPrice(asset *types.Asset, timestamp time.Time, currency string) (Price, bool, error) {
+	// The quote asset must be priced by another provider, and CW20 tokens are not traded inside the pools
+	if asset.HasDenom(p.twapCfg.QuoteDenom) || types.IsCW20Denom(asset.Base) {
+		return Price{}, false, nil
+	}
+
+	assets, err := types.GetAssets()
+	if err != nil {
+		return Price{}, false, err
+	}
+
+	quoteAsset, found := assets.GetAssetByCoinDenom(p.twapCfg.QuoteDenom)
+	if !found {
+		return Price{}, false, fmt.Errorf("quote asset not found: %s", p.twapCfg.QuoteDenom)
+	}
+
+	rep, err := p.getReporter(timestamp)
+	if err != nil {
+		return Price{}, false, err
+	}
+
+	blockData, err := rep.GetBlockNearTimestamp(timestamp, p.cfg.Report)
+	if err != nil {
+		return Price{}, false, err
+	}
+
+	if blockData.IsZero() {
+		// Osmosis did not exist at the given time
+		return Price{}, false, nil
+	}
+
+	osmosisReporter, err := osmosis.NewReporter(rep.GetConnection(), nil, p.cdc)
+	if err != nil {
+		return Price{}, false, err
+	}
+
+	twap, poolID, found, err := p.getTwap(osmosisReporter, asset.Base, blockData)
+	if err != nil || !found {
+		return Price{}, false, err
+	}
+
+	quotePrice, found, err := p.quotePrices.GetPriceData(quoteAsset, timestamp, currency)
+	if err != nil {
+		return Price{}, false, fmt.Errorf("error while getting the price of the quote asset: %w", err)
+	}
+
+	if !found {
+		// Without the price of the quote asset the TWAP cannot be converted
+		return Price{}, false, nil
+	}
+
+	// The TWAP is measured in base denoms, so we need to adjust it based on the exponents of the assets
+	twap = twap.MulInt(types.GetPower(asset.GetMaxExponent())).QuoInt(types.GetPower(quoteAsset.GetMaxExponent()))
+
+	twapValue, err := twap.Float64()
+	if err != nil {
+		return Price{}, false, err
+	}
+	price := twapValue * quotePrice.Price
+
+	log.Debug().Str("asset", asset.Symbol).Uint64("pool", poolID).Str("twap", twap.String()).
+		Float64("quote price", quotePrice.Price).Msg("computed twap price")
+
+	return NewPrice(price, blockData.Timestamp), true, nil
+}
+
+// Stop stops the reporters used to query Osmosis
+func (p *OsmosisTwapProvider) Stop() {
+	p.reporters.Stop()
+}
+
+// getReporter returns the reporter used to query the Osmosis era containing the given timestamp
+func (p *OsmosisTwapProvider) getReporter(timestamp time.Time) (*reporter.Reporter, error) {
+	chainCfg, found := p.cfg.GetChainConfig(p.twapCfg.Chain)
+	if !found {
+		return nil, fmt.Errorf("chain %s not found inside the config", p.twapCfg.Chain)
+	}
+
+	rep, err := p.reporters.GetReporter(chainCfg, timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating the %s reporter: %w", chainCfg.Name, err)
+	}
+
+	return rep, nil
+}
+
+// getTwap returns the TWAP of the given denom at the provided block, along with the id of the pool it was read from.
+// A pool found on chain for another height might not exist at the given one (e.g. inside a different era),
+// so if its TWAP cannot be read the pool is searched again at the given height.
+func (p *OsmosisTwapProvider) getTwap(
+	osmosisReporter *osmosis.Reporter, denom string, blockData types.BlockData,
+) (twap sdk.Dec, poolID uint64, found bool, err error) {
+	startTime := blockData.Timestamp.Add(-p.twapCfg.Window)
+
+	poolID, cached, found, err := p.getPoolID(osmosisReporter, denom, blockData.Height)
+	if err != nil || !found {
+		return sdk.Dec{}, 0, false, err
+	}
+
+	twap, err = osmosisReporter.GetTwapPrice(poolID, denom, p.twapCfg.QuoteDenom, startTime, blockData.Height)
+	if err == nil {
+		return twap, poolID, true, nil
+	}
+
+	if !cached {
+		return sdk.Dec{}, 0, false, err
+	}
+
+	log.Debug().Str("denom", denom).Uint64("pool", poolID).Int64("height", blockData.Height).Err(err).
+		Msg("error while getting the twap of the cached pool, searching the pool again")
+
+	poolID, found, err = p.findPool(osmosisReporter, denom, blockData.Height)
+	if err != nil || !found {
+		return sdk.Dec{}, 0, false, err
+	}
+
+	twap, err = osmosisReporter.GetTwapPrice(poolID, denom, p.twapCfg.QuoteDenom, startTime, blockData.Height)
+	if err != nil {
+		return sdk.Dec{}, 0, false, err
+	}
+
+	return twap, poolID, true, nil
+}
+
+// getPoolID returns the id of the pool that should be used to compute the TWAP of the given denom,
+// either the configured one or the one found on chain. Cached tells whether the pool has been found on chain
+// for a previous price, and thus might not be valid at the given height.
+func (p *OsmosisTwapProvider) getPoolID(
+	osmosisReporter *osmosis.Reporter, denom string, height int64,
+) (poolID uint64, cached bool, found bool, err error) {
+	if configuredID, ok := p.twapCfg.GetPoolID(denom); ok {
+		return configuredID, false, true, nil
+	}
+
+	p.mutex.Lock()
+	poolID, cached = p.pools[denom]
+	p.mutex.Unlock()
+	if cached {
+		return poolID, true, true, nil
+	}
+
+	poolID, found, err = p.findPool(osmosisReporter, denom, height)
+	return poolID, false, found, err
+}
+
+// findPool searches on chain the pool that should be used to compute the TWAP of the given denom at the given height,
+// caching the result so that other prices can use it
+func (p *OsmosisTwapProvider) findPool(osmosisReporter *osmosis.Reporter, denom string, height int64) (uint64, bool, error) {
+	poolID, found, err := osmosisReporter.FindPool(denom, p.twapCfg.QuoteDenom, height)
+	if err != nil {
+		return 0, false, err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !found {
+		delete(p.pools, denom)
+		return 0, false, nil
+	}
+
+	p.pools[denom] = poolID
+	return poolID, true, nil
+}
@@ -0,0 +1,230 @@
+package prices
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	"github.com/rs/zerolog/log"
+
+	"github.com/riccardom/briatore/types"
+)
+
+// PriceProvider represents a source of historical prices
+type PriceProvider interface {
+	// Name returns the name of the provider, which is used to enable it inside the config
+	Name() string
+
+	// GetPrice returns the price of the given asset at the provided point in time, measured in the given currency.
+	// If the provider does not support the asset, found is false.
+	GetPrice(asset *types.Asset, timestamp time.Time, currency string) (price Price, found bool, err error)
+}
+
+// Price represents the price of an asset returned by a provider
+type Price struct {
+	Value float64
+
+	// Timestamp is the time the price refers to, which might differ from the requested one
+	Timestamp time.Time
+}
+
+func NewPrice(value float64, timestamp time.Time) Price {
+	return Price{
+		Value:     value,
+		Timestamp: timestamp,
+	}
+}
+
+// PriceProviderCreator represents a function that allows to build a new PriceProvider instance.
+// The chain the provider will be part of is given so that it can be used to get the prices of other assets.
+type PriceProviderCreator func(cfg *types.Config, cdc codec.Codec, chain *Chain) (PriceProvider, error)
+
+var (
+	priceProviders = map[string]PriceProviderCreator{}
+)
+
+func init() {
+	RegisterPriceProvider(CoinGeckoProviderName, func(cfg *types.Config, _ codec.Codec, _ *Chain) (PriceProvider, error) {
+		return NewCoinGeckoProvider(cfg.Report.GetCoinGeckoConfig())
+	})
+	RegisterPriceProvider(OsmosisTwapProviderName, func(cfg *types.Config, cdc codec.Codec, chain *Chain) (PriceProvider, error) {
+		return NewOsmosisTwapProvider(cfg, cdc, chain), nil
+	})
+}
+
+// RegisterPriceProvider registers the given creator so that it can be used to build the price provider
+// having the provided name. Providers can be enabled by adding their name inside the report config.
+func RegisterPriceProvider(name string, creator PriceProviderCreator) {
+	if _, ok := priceProviders[name]; ok {
+		panic(fmt.Errorf("price provider %s already registered", name))
+	}
+	priceProviders[name] = creator
+}
+
+// --------------------------------------------------------------------------------------------------------------------
+
+// Chain queries a list of price providers in order, returning the first price found.
+// Prices are cached, and the ones requested for times within the given resolution are considered the same.
+// Manual prices, either configured or imported inside the cache, always take precedence over the providers.
+type Chain struct {
+	resolution time.Duration
+	providers  []PriceProvider
+
+	// location is the location used to compare the days of the manual prices and exchange rates
+	location     *time.Location
+	manualPrices []types.PriceData
+
+	// fxBaseCurrency is the currency prices are read in before being converted using the exchange rates.
+	// If empty, prices are read directly in the requested currency
+	fxBaseCurrency string
+}
+
+// NewChain returns a new Chain instance containing the given providers
+func NewChain(resolution time.Duration, providers ...PriceProvider) *Chain {
+	return &Chain{
+		resolution: resolution,
+		providers:  providers,
+		location:   time.UTC,
+	}
+}
+
+// NewChainFromConfig builds a new Chain containing the providers enabled inside the given config
+func NewChainFromConfig(cfg *types.Config, cdc codec.Codec) (*Chain, error) {
+	chain := NewChain(cfg.Report.GetPriceResolution())
+
+	location, err := cfg.Report.GetLocation()
+	if err != nil {
+		return nil, err
+	}
+	chain.location = location
+
+	for _, manualPrice := range cfg.Report.ManualPrices {
+		priceData, err := manualPrice.GetPriceData(location, cfg.Report.Currency)
+		if err != nil {
+			return nil, err
+		}
+		chain.manualPrices = append(chain.manualPrices, priceData)
+	}
+
+	if fxCfg := cfg.Report.GetFXConfig(); fxCfg != nil {
+		chain.fxBaseCurrency = fxCfg.BaseCurrency
+	}
+
+	for _, name := range cfg.Report.GetPriceProviders() {
+		creator, ok := priceProviders[name]
+		if !ok {
+			return nil, fmt.Errorf("price provider not found: %s", name)
+		}
+
+		provider, err := creator(cfg, cdc, chain)
+		if err != nil {
+			return nil, fmt.Errorf("error while creating %s price provider: %w", name, err)
+		}
+		chain.providers = append(chain.providers, provider)
+	}
+	return chain, nil
+}
+
+// Stop stops the providers that keep connections open, such as the ones that read the prices on chain
+func (c *Chain) Stop() {
+	for _, provider := range c.providers {
+		if stopper, ok := provider.(interface{ Stop() }); ok {
+			stopper.Stop()
+		}
+	}
+}
+
+// GetPriceData returns the price of the given asset at the provided point in time, measured in the given currency,
+// along with the provider that returned it. Prices are cached, so that each of them is fetched only once.
+// If no provider knows the price of the asset, found is false. If no provider returned the price and any of them
+// failed, the error is returned instead, so that the price is not mistaken for an unknown one.
+func (c *Chain) GetPriceData(asset *types.Asset, timestamp time.Time, currency string) (priceData types.PriceData, found bool, err error) {
+	priceData, found, err = c.getManualPriceData(asset, timestamp, currency)
+	if err != nil || found {
+		return priceData, found, err
+	}
+
+	if c.fxBaseCurrency != "" && !strings.EqualFold(currency, c.fxBaseCurrency) {
+		return c.getConvertedPriceData(asset, timestamp, currency)
+	}
+
+	priceData, found, err = types.GetPriceData(asset.Base, currency, timestamp, c.resolution)
+	if err != nil || found {
+		return priceData, found, err
+	}
+
+	var providerErr error
+	for _, provider := range c.providers {
+		price, found, err := provider.GetPrice(asset, timestamp, currency)
+		if err != nil {
+			log.Warn().Str("provider", provider.Name()).Str("asset", asset.Symbol).Err(err).
+				Msg("error while getting price, trying next provider")
+			providerErr = fmt.Errorf("error while getting the %s price from %s: %w", asset.Symbol, provider.Name(), err)
+			continue
+		}
+
+		if !found {
+			continue
+		}
+
+		priceData = types.NewPriceData(provider.Name(), asset.Base, price.Value, currency, timestamp, price.Timestamp, c.resolution)
+
+		// Cache the price data
+		err = types.CachePriceData(priceData)
+		if err != nil {
+			return types.PriceData{}, false, err
+		}
+
+		return priceData, true, nil
+	}
+
+	if providerErr != nil {
+		return types.PriceData{}, false, providerErr
+	}
+
+	log.Warn().Str("asset", asset.Symbol).Time("timestamp", timestamp).Msg("price not found in any provider")
+	return types.PriceData{}, false, nil
+}
+
+// getManualPriceData returns the manual price of the given asset for the day of the given timestamp, if any.
+// Configured prices take precedence over the ones that have been imported inside the cache.
+func (c *Chain) getManualPriceData(asset *types.Asset, timestamp time.Time, currency string) (types.PriceData, bool, error) {
+	for _, priceData := range c.manualPrices {
+		if priceData.IsManualPriceOf(asset, currency, timestamp, c.location) {
+			return priceData, true, nil
+		}
+	}
+
+	return types.GetManualPriceData(asset, currency, timestamp, c.location)
+}
+
+// getConvertedPriceData returns the price of the given asset read in the base currency and then converted
+// into the given currency using the official exchange rate of the day of the given timestamp
+func (c *Chain) getConvertedPriceData(asset *types.Asset, timestamp time.Time, currency string) (types.PriceData, bool, error) {
+	basePriceData, found, err := c.GetPriceData(asset, timestamp, c.fxBaseCurrency)
+	if err != nil || !found {
+		return types.PriceData{}, found, err
+	}
+
+	rate, found, err := types.GetExchangeRate(c.fxBaseCurrency, currency, timestamp, c.location)
+	if err != nil {
+		return types.PriceData{}, false, err
+	}
+
+	if !found {
+		return types.PriceData{}, false, fmt.Errorf("exchange rate between %s and %s not found for %s, please import it",
+			strings.ToUpper(c.fxBaseCurrency), strings.ToUpper(currency), types.GetDay(timestamp, c.location).Format("2006-01-02"))
+	}
+
+	price, err := rate.Convert(basePriceData.Price, c.fxBaseCurrency)
+	if err != nil {
+		return types.PriceData{}, false, err
+	}
+
+	priceData := basePriceData
+	priceData.Price = price
+	priceData.Currency = currency
+	priceData.ExchangeRate = &rate
+	return priceData, true, nil
+}
@@ -0,0 +1,218 @@
+package prices
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/riccardom/briatore/types"
+)
+
+// stubProvider is a PriceProvider that returns a fixed price, keeping track of the number of times it is queried
+type stubProvider struct {
+	name  string
+	price float64
+	found bool
+	err   error
+	calls int
+}
+
+func (p *stubProvider) Name() string {
+	return p.name
+}
+
+func (p *stubProvider) GetPrice(_ *types.Asset, timestamp time.Time, _ string) (Price, bool, error) {
+	p.calls++
+	if p.err != nil || !p.found {
+		return Price{}, false, p.err
+	}
+	return NewPrice(p.price, timestamp), true, nil
+}
+
+func TestChain_GetPriceData(t *testing.T) {
+	asset := &types.Asset{Base: "uatom", Symbol: "ATOM"}
+	timestamp := time.Date(2023, time.December, 31, 23, 59, 59, 0, time.UTC)
+
+	testCases := []struct {
+		name string
+
+		// setup caches any data needed before the test and returns the providers and manual prices to be used
+		setup func() ([]*stubProvider, []types.PriceData)
+
+		shouldErr        bool
+		expectedFound    bool
+		expectedPrice    float64
+		expectedProvider string
+		expectedCalls    []int
+	}{
+		{
+			name: "first provider that knows the price is used",
+			setup: func() ([]*stubProvider, []types.PriceData) {
+				return []*stubProvider{
+					{name: "first", price: 10, found: true},
+					{name: "second", price: 20, found: true},
+				}, nil
+			},
+			expectedFound:    true,
+			expectedPrice:    10,
+			expectedProvider: "first",
+			expectedCalls:    []int{1, 0},
+		},
+		{
+			name: "providers not knowing the price are skipped",
+			setup: func() ([]*stubProvider, []types.PriceData) {
+				return []*stubProvider{
+					{name: "first"},
+					{name: "second", price: 20, found: true},
+				}, nil
+			},
+			expectedFound:    true,
+			expectedPrice:    20,
+			expectedProvider: "second",
+			expectedCalls:    []int{1, 1},
+		},
+		{
+			name: "providers returning an error are skipped when another one knows the price",
+			setup: func() ([]*stubProvider, []types.PriceData) {
+				return []*stubProvider{
+					{name: "first", err: fmt.Errorf("rate limited")},
+					{name: "second", price: 20, found: true},
+				}, nil
+			},
+			expectedFound:    true,
+			expectedPrice:    20,
+			expectedProvider: "second",
+			expectedCalls:    []int{1, 1},
+		},
+		{
+			name: "price not known by any provider is not found",
+			setup: func() ([]*stubProvider, []types.PriceData) {
+				return []*stubProvider{
+					{name: "first"},
+					{name: "second"},
+				}, nil
+			},
+			expectedFound: false,
+			expectedCalls: []int{1, 1},
+		},
+		{
+			name: "provider error is returned when no other provider knows the price",
+			setup: func() ([]*stubProvider, []types.PriceData) {
+				return []*stubProvider{
+					{name: "first", err: fmt.Errorf("rate limited")},
+					{name: "second"},
+				}, nil
+			},
+			shouldErr:     true,
+			expectedCalls: []int{1, 1},
+		},
+		{
+			name: "cached price is used without querying the providers",
+			setup: func() ([]*stubProvider, []types.PriceData) {
+				cached := types.NewPriceData("cached", asset.Base, 5, "eur", timestamp, timestamp, types.Day)
+				if err := types.CachePriceData(cached); err != nil {
+					t.Fatalf("error while caching price: %s", err)
+				}
+				return []*stubProvider{{name: "first", price: 10, found: true}}, nil
+			},
+			expectedFound:    true,
+			expectedPrice:    5,
+			expectedProvider: "cached",
+			expectedCalls:    []int{0},
+		},
+		{
+			name: "configured manual price wins over providers and cache",
+			setup: func() ([]*stubProvider, []types.PriceData) {
+				cached := types.NewPriceData("cached", asset.Base, 5, "eur", timestamp, timestamp, types.Day)
+				if err := types.CachePriceData(cached); err != nil {
+					t.Fatalf("error while caching price: %s", err)
+				}
+				manual := types.NewManualPriceData(asset.Base, 7, "eur", timestamp, "OTC trade")
+				return []*stubProvider{{name: "first", price: 10, found: true}}, []types.PriceData{manual}
+			},
+			expectedFound:    true,
+			expectedPrice:    7,
+			expectedProvider: types.ManualPriceProvider,
+			expectedCalls:    []int{0},
+		},
+		{
+			name: "imported manual price wins over providers",
+			setup: func() ([]*stubProvider, []types.PriceData) {
+				manual := types.NewManualPriceData(asset.Base, 8, "eur", timestamp, "OTC trade")
+				if err := types.CacheManualPrices([]types.PriceData{manual}, time.UTC); err != nil {
+					t.Fatalf("error while caching manual price: %s", err)
+				}
+				return []*stubProvider{{name: "first", price: 10, found: true}}, nil
+			},
+			expectedFound:    true,
+			expectedPrice:    8,
+			expectedProvider: types.ManualPriceProvider,
+			expectedCalls:    []int{0},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			types.HomePath = t.TempDir()
+
+			stubs, manualPrices := tc.setup()
+			providers := make([]PriceProvider, len(stubs))
+			for i, stub := range stubs {
+				providers[i] = stub
+			}
+
+			chain := NewChain(types.Day, providers...)
+			chain.manualPrices = manualPrices
+
+			priceData, found, err := chain.GetPriceData(asset, timestamp, "eur")
+			for i, stub := range stubs {
+				if stub.calls != tc.expectedCalls[i] {
+					t.Errorf("expected provider %s to be called %d times, got %d", stub.name, tc.expectedCalls[i], stub.calls)
+				}
+			}
+
+			if tc.shouldErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if found != tc.expectedFound {
+				t.Fatalf("expected found %t, got %t", tc.expectedFound, found)
+			}
+			if found && (priceData.Price != tc.expectedPrice || priceData.Provider != tc.expectedProvider) {
+				t.Errorf("expected price %f from %s, got %f from %s",
+					tc.expectedPrice, tc.expectedProvider, priceData.Price, priceData.Provider)
+			}
+		})
+	}
+}
+
+func TestChain_GetPriceData_CachesProviderPrices(t *testing.T) {
+	types.HomePath = t.TempDir()
+
+	asset := &types.Asset{Base: "uatom", Symbol: "ATOM"}
+	timestamp := time.Date(2023, time.December, 31, 23, 59, 59, 0, time.UTC)
+
+	provider := &stubProvider{name: "first", price: 10, found: true}
+	chain := NewChain(types.Day, provider)
+
+	for i := 0; i < 2; i++ {
+		priceData, found, err := chain.GetPriceData(asset, timestamp.Add(-time.Duration(i)*time.Hour), "eur")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !found || priceData.Price != 10 {
+			t.Fatalf("expected price 10, got %f (found: %t)", priceData.Price, found)
+		}
+	}
+
+	if provider.calls != 1 {
+		t.Errorf("expected provider to be called once, got %d", provider.calls)
+	}
+}
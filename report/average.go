@@ -0,0 +1,220 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/gocarina/gocsv"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+
+	"github.com/riccardom/briatore/types"
+)
+
+// GetAverageReport returns the average holdings of the given addresses between the provided dates.
+// To do this, the report is computed at each interval starting from the first date and up to the last one
+// (both included), and the amounts and values of each asset are averaged across all the samples.
+func GetAverageReport(cfg *types.Config, addresses []string, from, to time.Time, interval time.Duration) (*types.AverageReport, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid interval: %s", interval)
+	}
+
+	if to.Before(from) {
+		return nil, fmt.Errorf("end date %s is before start date %s", to, from)
+	}
+
+	location, err := cfg.Report.GetLocation()
+	if err != nil {
+		return nil, err
+	}
+
+	// Reuse the same reporters and prices across all the samples
+	s, err := newSession(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer s.stop()
+
+	averages := newAveragesBuilder()
+
+	var warnings []string
+	for _, date := range getSampleDates(from, to, interval, location) {
+		log.Info().Time("date", date).Msg("sampling holdings")
+
+		result := s.getReport(addresses, date)
+		if result.IsError() {
+			return nil, fmt.Errorf("error while getting the report at %s: %w", date, result.Err())
+		}
+
+		// A sample missing the amounts of any chain would lower the averages and hide the peaks
+		err = result.Metadata.ValidateComplete()
+		if err != nil {
+			return nil, fmt.Errorf("error while getting the report at %s: %w", date, err)
+		}
+
+		for _, warning := range result.Metadata.Warnings {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", date.Format(time.RFC3339), warning))
+		}
+
+		averages.addSample(date, result.Amounts)
+	}
+
+	assets, total := averages.getAverages()
+
+	thresholds, err := checkThresholds(cfg.Report.GetAverageConfig().Thresholds, total)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.AverageReport{
+		From:       from,
+		To:         to,
+		Interval:   interval.String(),
+		Samples:    averages.samples,
+		Assets:     assets,
+		Total:      total,
+		Thresholds: thresholds,
+		Warnings:   warnings,
+	}, nil
+}
+
+// getSampleDates returns the dates at which the holdings are sampled between the given ones, both included.
+// Intervals made of whole days are stepped by calendar days inside the given location, so that the samples are
+// always taken at the same local time even across daylight saving time changes.
+func getSampleDates(from, to time.Time, interval time.Duration, location *time.Location) []time.Time {
+	from = from.In(location)
+
+	var dates []time.Time
+	for i := 0; ; i++ {
+		date := from.Add(time.Duration(i) * interval)
+		if interval%types.Day == 0 {
+			date = from.AddDate(0, 0, i*int(interval/types.Day))
+		}
+
+		if date.After(to) {
+			return dates
+		}
+		dates = append(dates, date)
+	}
+}
+
+// averagesBuilder accumulates the samples of the holdings to compute their averages and peaks
+type averagesBuilder struct {
+	samples int64
+	symbols []string
+	assets  map[string]*types.AssetAverage
+	total   *types.AssetAverage
+}
+
+func newAveragesBuilder() *averagesBuilder {
+	return &averagesBuilder{
+		assets: map[string]*types.AssetAverage{},
+		total:  types.NewAssetAverage("total"),
+	}
+}
+
+// addSample adds the given amounts, held at the provided date, to the samples.
+// Until all the samples have been added, the averages contain the sums of the sampled values.
+func (b *averagesBuilder) addSample(date time.Time, amounts []*types.Amount) {
+	b.samples++
+
+	// Sum the amounts of the same asset across all chains, addresses and categories
+	merged := map[string]*types.Amount{}
+	sampleTotal := sdk.ZeroDec()
+	for _, amount := range amounts {
+		symbol := amount.Asset.Symbol
+		if existing, ok := merged[symbol]; ok {
+			existing.Amount = existing.Amount.Add(amount.Amount)
+			existing.Value = existing.Value.Add(amount.Value)
+		} else {
+			merged[symbol] = types.NewAmount(amount.Asset, types.Origin{}, "", amount.Amount, amount.Value)
+		}
+
+		sampleTotal = sampleTotal.Add(amount.Value)
+	}
+
+	for symbol, amount := range merged {
+		average, ok := b.assets[symbol]
+		if !ok {
+			b.symbols = append(b.symbols, symbol)
+			average = types.NewAssetAverage(symbol)
+			b.assets[symbol] = average
+		}
+
+		addToAverage(average, date, amount.Amount, amount.Value)
+	}
+
+	addToAverage(b.total, date, sdk.ZeroDec(), sampleTotal)
+}
+
+// addToAverage adds the given amount and value, held at the provided date, to the sums of the given average,
+// updating its peak if needed
+func addToAverage(average *types.AssetAverage, date time.Time, amount sdk.Dec, value sdk.Dec) {
+	average.AverageAmount = average.AverageAmount.Add(amount)
+	average.AverageValue = average.AverageValue.Add(value)
+
+	if average.PeakDate.IsZero() || value.GT(average.PeakValue) {
+		average.PeakAmount = amount
+		average.PeakValue = value
+		average.PeakDate = date
+	}
+}
+
+// getAverages returns the averages of each asset, sorted by asset, and the average of the total value.
+// Assets that were not held at the time of a sample count as zero for that sample.
+func (b *averagesBuilder) getAverages() ([]*types.AssetAverage, *types.AssetAverage) {
+	sort.Strings(b.symbols)
+
+	assets := make([]*types.AssetAverage, len(b.symbols))
+	for i, symbol := range b.symbols {
+		assets[i] = b.assets[symbol]
+		assets[i].AverageAmount = assets[i].AverageAmount.QuoInt64(b.samples)
+		assets[i].AverageValue = assets[i].AverageValue.QuoInt64(b.samples)
+	}
+
+	b.total.AverageValue = b.total.AverageValue.QuoInt64(b.samples)
+	return assets, b.total
+}
+
+// checkThresholds compares the given total holdings against the provided thresholds
+func checkThresholds(thresholds []*types.ThresholdConfig, total *types.AssetAverage) ([]*types.ThresholdCheck, error) {
+	checks := make([]*types.ThresholdCheck, len(thresholds))
+	for i, threshold := range thresholds {
+		value, err := threshold.GetValue()
+		if err != nil {
+			return nil, err
+		}
+
+		measure, err := threshold.GetMeasure()
+		if err != nil {
+			return nil, err
+		}
+
+		measuredValue := total.AverageValue
+		if measure == types.ThresholdMeasurePeak {
+			measuredValue = total.PeakValue
+		}
+
+		checks[i] = types.NewThresholdCheck(threshold.Name, measure, value, measuredValue)
+	}
+
+	return checks, nil
+}
+
+// MarshalAverage marshals the given average report based on the provided output
+func MarshalAverage(averageReport *types.AverageReport, output types.Output) ([]byte, error) {
+	switch output {
+	case types.OutText:
+		return yaml.Marshal(averageReport)
+	case types.OutJSON:
+		return json.Marshal(averageReport)
+	case types.OutCSV:
+		averages := types.FormatAverage(averageReport)
+		return gocsv.MarshalBytes(&averages)
+	default:
+		return nil, fmt.Errorf("invalid average output value: %s", output)
+	}
+}
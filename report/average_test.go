@@ -0,0 +1,197 @@
+package report
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/riccardom/briatore/types"
+)
+
+func TestGetSampleDates(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	if err != nil {
+		t.Fatalf("error while loading location: %s", err)
+	}
+
+	testCases := []struct {
+		name     string
+		from     time.Time
+		to       time.Time
+		interval time.Duration
+		expected []time.Time
+	}{
+		{
+			name:     "daily samples keep the same local time across daylight saving time changes",
+			from:     time.Date(2023, time.March, 24, 23, 59, 59, 0, rome),
+			to:       time.Date(2023, time.March, 27, 23, 59, 59, 0, rome),
+			interval: types.Day,
+			expected: []time.Time{
+				time.Date(2023, time.March, 24, 23, 59, 59, 0, rome),
+				time.Date(2023, time.March, 25, 23, 59, 59, 0, rome),
+				time.Date(2023, time.March, 26, 23, 59, 59, 0, rome),
+				time.Date(2023, time.March, 27, 23, 59, 59, 0, rome),
+			},
+		},
+		{
+			name:     "multiple days samples keep the same local time",
+			from:     time.Date(2023, time.October, 20, 23, 59, 59, 0, rome),
+			to:       time.Date(2023, time.November, 5, 23, 59, 59, 0, rome),
+			interval: 7 * types.Day,
+			expected: []time.Time{
+				time.Date(2023, time.October, 20, 23, 59, 59, 0, rome),
+				time.Date(2023, time.October, 27, 23, 59, 59, 0, rome),
+				time.Date(2023, time.November, 3, 23, 59, 59, 0, rome),
+			},
+		},
+		{
+			name:     "dates are read inside the location",
+			from:     time.Date(2023, time.March, 25, 22, 59, 59, 0, time.UTC),
+			to:       time.Date(2023, time.March, 26, 21, 59, 59, 0, time.UTC),
+			interval: types.Day,
+			expected: []time.Time{
+				time.Date(2023, time.March, 25, 23, 59, 59, 0, rome),
+				time.Date(2023, time.March, 26, 23, 59, 59, 0, rome),
+			},
+		},
+		{
+			name:     "intervals shorter than a day are stepped by duration",
+			from:     time.Date(2023, time.March, 26, 0, 0, 0, 0, rome),
+			to:       time.Date(2023, time.March, 26, 12, 0, 0, 0, rome),
+			interval: 6 * time.Hour,
+			expected: []time.Time{
+				time.Date(2023, time.March, 26, 0, 0, 0, 0, rome),
+				time.Date(2023, time.March, 26, 7, 0, 0, 0, rome),
+			},
+		},
+		{
+			name:     "single sample when the dates are the same",
+			from:     time.Date(2023, time.December, 31, 23, 59, 59, 0, rome),
+			to:       time.Date(2023, time.December, 31, 23, 59, 59, 0, rome),
+			interval: types.Day,
+			expected: []time.Time{
+				time.Date(2023, time.December, 31, 23, 59, 59, 0, rome),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dates := getSampleDates(tc.from, tc.to, tc.interval, rome)
+			if len(dates) != len(tc.expected) {
+				t.Fatalf("expected %d dates, got %d: %v", len(tc.expected), len(dates), dates)
+			}
+
+			for i, expected := range tc.expected {
+				if !dates[i].Equal(expected) {
+					t.Errorf("date %d: expected %s, got %s", i, expected, dates[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAveragesBuilder(t *testing.T) {
+	atom := &types.Asset{Name: "Cosmos Hub", Symbol: "ATOM"}
+	osmo := &types.Asset{Name: "Osmosis", Symbol: "OSMO"}
+
+	first := time.Date(2023, time.January, 1, 23, 59, 59, 0, time.UTC)
+	second := first.AddDate(0, 0, 1)
+	third := first.AddDate(0, 0, 2)
+
+	newAmount := func(asset *types.Asset, chain string, category types.Category, amount int64, value int64) *types.Amount {
+		origin := types.NewOrigin(chain, "address", 1, first)
+		return types.NewAmount(asset, origin, category, sdk.NewDec(amount), sdk.NewDec(value))
+	}
+
+	builder := newAveragesBuilder()
+	builder.addSample(first, []*types.Amount{
+		newAmount(atom, "cosmos", types.CategoryBank, 10, 100),
+		newAmount(atom, "osmosis", types.CategoryBank, 2, 20),
+		newAmount(osmo, "osmosis", types.CategoryDelegated, 30, 30),
+	})
+	builder.addSample(second, []*types.Amount{
+		newAmount(atom, "cosmos", types.CategoryDelegated, 30, 240),
+	})
+	builder.addSample(third, nil)
+
+	assets, total := builder.getAverages()
+
+	if builder.samples != 3 {
+		t.Fatalf("expected 3 samples, got %d", builder.samples)
+	}
+
+	type expectedAverage struct {
+		asset         string
+		averageAmount int64
+		averageValue  int64
+		peakAmount    int64
+		peakValue     int64
+		peakDate      time.Time
+	}
+
+	expected := []expectedAverage{
+		{asset: "ATOM", averageAmount: 14, averageValue: 120, peakAmount: 30, peakValue: 240, peakDate: second},
+		{asset: "OSMO", averageAmount: 10, averageValue: 10, peakAmount: 30, peakValue: 30, peakDate: first},
+		{asset: "total", averageAmount: 0, averageValue: 130, peakAmount: 0, peakValue: 240, peakDate: second},
+	}
+
+	if len(assets) != 2 {
+		t.Fatalf("expected 2 assets, got %d", len(assets))
+	}
+
+	for i, average := range append(assets, total) {
+		exp := expected[i]
+		if average.Asset != exp.asset {
+			t.Errorf("average %d: expected asset %s, got %s", i, exp.asset, average.Asset)
+		}
+		if !average.AverageAmount.Equal(sdk.NewDec(exp.averageAmount)) {
+			t.Errorf("%s: expected average amount %d, got %s", exp.asset, exp.averageAmount, average.AverageAmount)
+		}
+		if !average.AverageValue.Equal(sdk.NewDec(exp.averageValue)) {
+			t.Errorf("%s: expected average value %d, got %s", exp.asset, exp.averageValue, average.AverageValue)
+		}
+		if !average.PeakAmount.Equal(sdk.NewDec(exp.peakAmount)) {
+			t.Errorf("%s: expected peak amount %d, got %s", exp.asset, exp.peakAmount, average.PeakAmount)
+		}
+		if !average.PeakValue.Equal(sdk.NewDec(exp.peakValue)) {
+			t.Errorf("%s: expected peak value %d, got %s", exp.asset, exp.peakValue, average.PeakValue)
+		}
+		if !average.PeakDate.Equal(exp.peakDate) {
+			t.Errorf("%s: expected peak date %s, got %s", exp.asset, exp.peakDate, average.PeakDate)
+		}
+	}
+}
+
+func TestCheckThresholds(t *testing.T) {
+	total := types.NewAssetAverage("total")
+	total.AverageValue = sdk.NewDec(40_000)
+	total.PeakValue = sdk.NewDec(60_000)
+
+	thresholds := []*types.ThresholdConfig{
+		{Name: "average", Value: "51645.69"},
+		{Name: "peak", Value: "51645.69", Measure: "peak"},
+	}
+
+	checks, err := checkThresholds(thresholds, total)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(checks) != 2 {
+		t.Fatalf("expected 2 checks, got %d", len(checks))
+	}
+	if checks[0].Exceeded {
+		t.Errorf("expected average threshold not to be exceeded")
+	}
+	if !checks[1].Exceeded {
+		t.Errorf("expected peak threshold to be exceeded")
+	}
+
+	_, err = checkThresholds([]*types.ThresholdConfig{{Name: "invalid", Value: "abc"}}, total)
+	if err == nil {
+		t.Errorf("expected error for invalid threshold value")
+	}
+}
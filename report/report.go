@@ -3,6 +3,7 @@ package report
 import (
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/gocarina/gocsv"
@@ -10,62 +11,273 @@ import (
 	"github.com/rs/zerolog/log"
 	"gopkg.in/yaml.v3"
 
+	"github.com/riccardom/briatore/prices"
 	"github.com/riccardom/briatore/reporter"
 	"github.com/riccardom/briatore/types"
+	"github.com/riccardom/briatore/utils"
 )
 
 // GetReport returns the serialized report bytes for the given configuration, addresses and date.
 // The report will be serialized properly based on the given output type.
 func GetReport(cfg *types.Config, addresses []string, date time.Time) *types.ReportResult {
+	s, err := newSession(cfg)
+	if err != nil {
+		return types.NewErrorReportResult(err)
+	}
+	defer s.stop()
+
+	return s.getReport(addresses, date)
+}
+
+// session contains the data shared by the reports computed at different dates, such as the price providers and
+// the reporters of the chains, so that they are created only once. It must be stopped once it is not used anymore.
+type session struct {
+	cfg       *types.Config
+	prices    *prices.Chain
+	reporters *reporter.Reporters
+	workers   *utils.WorkerPool
+}
+
+func newSession(cfg *types.Config) (*session, error) {
 	cdc, _ := app.MakeCodecs()
 
-	var amounts []*types.Amount
-	for _, chain := range cfg.Chains {
-		log.Info().Str("chain", chain.Name).Msg("getting report")
+	priceSource, err := prices.NewChainFromConfig(cfg, cdc)
+	if err != nil {
+		return nil, err
+	}
 
-		addresses, err := types.GetUniqueSupportedAddresses(chain, addresses)
+	return &session{
+		cfg:       cfg,
+		prices:    priceSource,
+		reporters: reporter.NewReporters(cdc, priceSource),
+		workers:   utils.NewWorkerPool(cfg.Report.GetConcurrency().MaxWorkers),
+	}, nil
+}
+
+// stop stops the reporters and the price providers used by the session
+func (s *session) stop() {
+	s.reporters.Stop()
+	s.prices.Stop()
+}
+
+// getReport returns the report of the given addresses at the provided date
+func (s *session) getReport(addresses []string, date time.Time) *types.ReportResult {
+	cfg := s.cfg
+
+	// Get the supported addresses of each chain before starting to fetch any data
+	chainsAddresses := make([][]string, len(cfg.Chains))
+	for i, chain := range cfg.Chains {
+		chainAddresses, err := types.GetUniqueSupportedAddresses(chain, addresses)
 		if err != nil {
 			return types.NewErrorReportResult(err)
 		}
+		chainsAddresses[i] = chainAddresses
+	}
+
+	// Fetch the chains concurrently, storing the amounts by index so that the ordering is deterministic
+	chainsReports := make([]chainReport, len(cfg.Chains))
+
+	var wg sync.WaitGroup
+	for i, chain := range cfg.Chains {
+		wg.Add(1)
+		go func(i int, chain *types.ChainConfig) {
+			defer wg.Done()
+			chainsReports[i] = s.getChainReport(chain, chainsAddresses[i], date)
+		}(i, chain)
+	}
+	wg.Wait()
 
-		if len(addresses) == 0 {
-			log.Info().Str("chain", chain.Name).Msg("no supported addresses found, skipping")
+	metadata := types.NewReportMetadata(date, cfg.Report.GetBlockPolicy(), cfg.Report.GetMaxBlockGap())
+
+	var amounts []*types.Amount
+	for _, chainReport := range chainsReports {
+		if chainReport.Err != nil {
+			metadata.AddFailedChain(chainReport.ChainName, chainReport.Err)
 			continue
 		}
 
-		log.Debug().Str("chain", chain.Name).Msg("creating reporter")
-		rep, err := reporter.NewReporter(chain, cdc)
-		if err != nil {
-			log.Error().Str("chain", chain.Name).Err(err).Msg("error while creating the reporter")
+		amounts = append(amounts, chainReport.Amounts...)
+
+		block := chainReport.Block
+		if block.IsZero() {
 			continue
 		}
 
-		log.Debug().Str("chain", chain.Name).Msg("getting report data")
-		chainAmounts, err := rep.GetAmounts(addresses, date, cfg.Report)
-		if err != nil {
-			log.Error().Str("chain", chain.Name).Err(err).Msg("error while getting the amounts")
+		gap := block.Timestamp.Sub(date).Abs()
+		metadata.Blocks = append(metadata.Blocks,
+			types.NewReportBlock(block.ChainName, chainReport.ChainID, block.Height, block.Timestamp, gap, chainReport.Endpoints),
+		)
+
+		if gap > cfg.Report.GetMaxBlockGap() {
+			log.Warn().Str("chain", block.ChainName).Int64("height", block.Height).Dur("gap", gap).
+				Msg("block is too far from the requested date")
+			metadata.AddWarning("%s block %d is %s away from the requested date", block.ChainName, block.Height, gap)
+		}
+	}
+
+	addManualPricesWarnings(metadata, amounts)
+	addMissingPricesWarnings(metadata, amounts)
+	for _, amount := range amounts {
+		if amount.ExchangeRate != nil {
+			metadata.AddExchangeRate(*amount.ExchangeRate)
+		}
+	}
+
+	// Keep the various amounts separate so that they can later be grouped as needed
+	return types.NewAmountsReportResult(amounts, metadata)
+}
+
+// addManualPricesWarnings adds a warning to the given metadata for each asset that has been valued
+// using a manual price, so that such values can be told apart from the ones read from the providers
+func addManualPricesWarnings(metadata *types.ReportMetadata, amounts []*types.Amount) {
+	warned := map[string]bool{}
+	for _, amount := range amounts {
+		key := fmt.Sprintf("%s/%s", amount.Asset.Symbol, amount.PriceSource)
+		if !amount.ManualPrice || warned[key] {
 			continue
 		}
+		warned[key] = true
 
-		amounts = append(amounts, chainAmounts...)
+		source := amount.PriceSource
+		if source == "" {
+			source = "not specified"
+		}
+		metadata.AddWarning("%s has been valued using a manual price (source: %s)", amount.Asset.Symbol, source)
+	}
+}
 
-		log.Info().Str("chain", chain.Name).Msg("report retrieved")
+// addMissingPricesWarnings adds a warning to the given metadata for each asset that has been valued at zero
+// since its price was not found
+func addMissingPricesWarnings(metadata *types.ReportMetadata, amounts []*types.Amount) {
+	warned := map[string]bool{}
+	for _, amount := range amounts {
+		if !amount.MissingPrice || warned[amount.Asset.Symbol] {
+			continue
+		}
+		warned[amount.Asset.Symbol] = true
+
+		metadata.AddWarning("%s has been valued at zero since its price was not found", amount.Asset.Symbol)
 	}
+}
+
+// chainReport contains the data that has been read from a single chain
+type chainReport struct {
+	ChainName string
+	Block     types.BlockData
+	ChainID   string
+	Endpoints []string
+	Amounts   []*types.Amount
 
-	// Merge the various amounts and format them
-	return types.NewAmountsReportResult(types.Format(types.MergeSameAssetsAmounts(amounts)))
+	// Err is the error that prevented the amounts of the chain from being read, if any
+	Err error
 }
 
-// MarshalAmounts marshals the given amount based on the provided output
-func MarshalAmounts(amounts []types.AmountOutput, output types.Output) ([]byte, error) {
+// getChainReport returns the amounts that the given addresses hold on the provided chain at the given date,
+// along with the block that has been used to read them and the endpoints that served them.
+// Any error is logged and returned inside the report, so that it does not affect the other chains.
+func (s *session) getChainReport(chain *types.ChainConfig, addresses []string, date time.Time) chainReport {
+	log.Info().Str("chain", chain.Name).Msg("getting report")
+
+	if len(addresses) == 0 {
+		log.Info().Str("chain", chain.Name).Msg("no supported addresses found, skipping")
+		return chainReport{}
+	}
+
+	log.Debug().Str("chain", chain.Name).Msg("getting reporter")
+	rep, err := s.reporters.GetReporter(chain, date)
+	if err != nil {
+		log.Error().Str("chain", chain.Name).Err(err).Msg("error while creating the reporter")
+		return chainReport{ChainName: chain.Name, Err: fmt.Errorf("error while creating the reporter: %w", err)}
+	}
+
+	log.Debug().Str("chain", chain.Name).Msg("getting report data")
+	blockData, amounts, err := rep.GetAmounts(addresses, date, s.cfg, s.workers)
+	if err != nil {
+		log.Error().Str("chain", chain.Name).Err(err).Msg("error while getting the amounts")
+		return chainReport{ChainName: chain.Name, Err: fmt.Errorf("error while getting the amounts: %w", err)}
+	}
+
+	log.Info().Str("chain", chain.Name).Strs("endpoints", rep.GetServedEndpoints()).Msg("report retrieved")
+
+	return chainReport{
+		ChainName: chain.Name,
+		Block:     blockData,
+		ChainID:   rep.GetChainID(),
+		Endpoints: rep.GetServedEndpoints(),
+		Amounts:   amounts,
+	}
+}
+
+// MarshalAmounts marshals the given amounts, along with the metadata of the report they belong to,
+// based on the provided output
+func MarshalAmounts(amounts []types.AmountOutput, metadata *types.ReportMetadata, output types.Output) ([]byte, error) {
+	return MarshalReport(amounts, nil, metadata, output)
+}
+
+// reportOutput contains the amounts of a report along with the tax due on them and the details of how they were read
+type reportOutput struct {
+	Amounts  []types.AmountOutput  `yaml:"amounts" json:"amounts"`
+	Tax      *types.TaxReport      `yaml:"tax,omitempty" json:"tax,omitempty"`
+	Metadata *types.ReportMetadata `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+}
+
+// MarshalReport marshals the given amounts, tax report and metadata based on the provided output.
+// If neither a tax report nor the metadata are given, only the amounts are marshaled.
+// When using the CSV output, each of them is marshaled as a separate table.
+func MarshalReport(
+	amounts []types.AmountOutput, taxReport *types.TaxReport, metadata *types.ReportMetadata, output types.Output,
+) ([]byte, error) {
 	switch output {
 	case types.OutText:
-		return yaml.Marshal(&amounts)
+		if taxReport == nil && metadata == nil {
+			return yaml.Marshal(&amounts)
+		}
+		return yaml.Marshal(&reportOutput{Amounts: amounts, Tax: taxReport, Metadata: metadata})
 	case types.OutJSON:
-		return json.Marshal(&amounts)
+		if taxReport == nil && metadata == nil {
+			return json.Marshal(&amounts)
+		}
+		return json.Marshal(&reportOutput{Amounts: amounts, Tax: taxReport, Metadata: metadata})
 	case types.OutCSV:
-		return gocsv.MarshalBytes(&amounts)
+		bz, err := gocsv.MarshalBytes(&amounts)
+		if err != nil {
+			return nil, err
+		}
+
+		if taxReport != nil {
+			taxes := types.FormatTax(taxReport)
+			bz, err = appendCSVTable(bz, &taxes)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if metadata != nil {
+			blocks := types.FormatBlocks(metadata)
+			bz, err = appendCSVTable(bz, &blocks)
+			if err != nil {
+				return nil, err
+			}
+
+			fields := types.FormatMetadata(metadata)
+			bz, err = appendCSVTable(bz, &fields)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return bz, nil
 	default:
 		return nil, fmt.Errorf("invalid output value: %s", output)
 	}
 }
+
+// appendCSVTable marshals the given rows as a CSV table and appends it to the provided bytes, separating the two
+// tables with an empty line
+func appendCSVTable(bz []byte, rows interface{}) ([]byte, error) {
+	tableBz, err := gocsv.MarshalBytes(rows)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(bz, '\n'), tableBz...), nil
+}
@@ -0,0 +1,170 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/gocarina/gocsv"
+
+	"github.com/riccardom/briatore/tax"
+	"github.com/riccardom/briatore/types"
+)
+
+// GetRWReport returns the Quadro RW for the given year and addresses.
+// To do this, the report is computed at both the start of the first day and the end of the last day of the year,
+// and the amounts of each asset are grouped by their ownership share and days held.
+func GetRWReport(cfg *types.Config, addresses []string, year int) (*types.RWReport, error) {
+	location, err := cfg.Report.GetLocation()
+	if err != nil {
+		return nil, err
+	}
+
+	startDate := time.Date(year, time.January, 1, 0, 0, 0, 0, location)
+	endDate := types.EndOfDay(time.Date(year, time.December, 31, 0, 0, 0, 0, location))
+
+	s, err := newSession(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer s.stop()
+
+	initialResult := s.getReport(addresses, startDate)
+	if initialResult.IsError() {
+		return nil, fmt.Errorf("error while getting the initial report: %w", initialResult.Err())
+	}
+
+	finalResult := s.getReport(addresses, endDate)
+	if finalResult.IsError() {
+		return nil, fmt.Errorf("error while getting the final report: %w", finalResult.Err())
+	}
+
+	// The Quadro RW must contain the whole holdings, so it cannot be computed if any chain is missing
+	err = initialResult.Metadata.ValidateComplete()
+	if err != nil {
+		return nil, fmt.Errorf("error while getting the initial report: %w", err)
+	}
+
+	err = finalResult.Metadata.ValidateComplete()
+	if err != nil {
+		return nil, fmt.Errorf("error while getting the final report: %w", err)
+	}
+
+	builder := newRWBuilder(cfg.Report.GetTaxConfig().GetIVCAConfig(), tax.NewPeriod(endDate, location))
+
+	err = builder.addAmounts(initialResult.Amounts, func(row *types.RWRow, value sdk.Dec) {
+		row.InitialValue = row.InitialValue.Add(value)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = builder.addAmounts(finalResult.Amounts, func(row *types.RWRow, value sdk.Dec) {
+		row.FinalValue = row.FinalValue.Add(value)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	for _, result := range []*types.ReportResult{initialResult, finalResult} {
+		for _, warning := range result.Metadata.Warnings {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", result.Metadata.Date.Format(time.RFC3339), warning))
+		}
+	}
+
+	return types.NewRWReport(year, builder.getRows(), warnings), nil
+}
+
+// rwBuilder groups the amounts of the reports into the rows of the Quadro RW
+type rwBuilder struct {
+	cfg    *types.IVCAConfig
+	period tax.Period
+	keys   []string
+	rows   map[string]*types.RWRow
+}
+
+func newRWBuilder(cfg *types.IVCAConfig, period tax.Period) *rwBuilder {
+	return &rwBuilder{
+		cfg:    cfg,
+		period: period,
+		rows:   map[string]*types.RWRow{},
+	}
+}
+
+// addAmounts adds the given amounts to the rows having the same asset, ownership share and days held,
+// using the provided function to update their values
+func (b *rwBuilder) addAmounts(amounts []*types.Amount, addValue func(row *types.RWRow, value sdk.Dec)) error {
+	for _, amount := range amounts {
+		holding, err := b.period.GetHolding(b.cfg, amount)
+		if err != nil {
+			return err
+		}
+
+		key := fmt.Sprintf("%s/%s/%d", amount.Asset.Symbol, holding.Share, holding.DaysHeld)
+		row, ok := b.rows[key]
+		if !ok {
+			b.keys = append(b.keys, key)
+			row = types.NewRWRow(amount.Asset.Symbol, types.RWCryptoAssetCode, holding.Share.MulInt64(100), holding.DaysHeld)
+			b.rows[key] = row
+		}
+
+		addValue(row, amount.Value)
+	}
+
+	return nil
+}
+
+// getRows returns the rows of the Quadro RW, sorted by asset and ownership percentage
+func (b *rwBuilder) getRows() []*types.RWRow {
+	rows := make([]*types.RWRow, len(b.keys))
+	for i, key := range b.keys {
+		rows[i] = b.rows[key]
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Asset != rows[j].Asset {
+			return rows[i].Asset < rows[j].Asset
+		}
+		return rows[i].OwnershipPercentage.GT(rows[j].OwnershipPercentage)
+	})
+
+	return rows
+}
+
+// MarshalRW marshals the given Quadro RW based on the provided output
+func MarshalRW(rwReport *types.RWReport, output types.Output) ([]byte, error) {
+	rows := types.FormatRW(rwReport)
+
+	switch output {
+	case types.OutRW:
+		return printRW(rwReport.Year, rows)
+	case types.OutRWCSV:
+		return gocsv.MarshalBytes(&rows)
+	default:
+		return nil, fmt.Errorf("invalid Quadro RW output value: %s", output)
+	}
+}
+
+// printRW returns the printable layout of the given Quadro RW rows
+func printRW(year int, rows []types.RWRowOutput) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Quadro RW - Periodo d'imposta %d\n\n", year)
+
+	writer := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(writer, "Cripto-attività\tCodice\tQuota di possesso (%)\tGiorni\tValore iniziale\tValore finale\t")
+	for _, row := range rows {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			row.Asset, row.Code, row.OwnershipPercentage, row.DaysHeld, row.InitialValue, row.FinalValue)
+	}
+
+	err := writer.Flush()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
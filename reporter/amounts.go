@@ -1,23 +1,48 @@
 package reporter
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"strings"
 
-	"github.com/riccardom/briatore/reporter/osmosis"
+	"github.com/riccardom/briatore/types"
 	"github.com/riccardom/briatore/utils"
 
 	"github.com/rs/zerolog/log"
 
+	wasmtypes "github.com/CosmWasm/wasmd/x/wasm/types"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/types/query"
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
 )
 
+// getBondDenom returns the denom of the token used for staking at the given height.
+// The asset set inside the chain config takes precedence over the staking params,
+// while the assets list is used as a fallback if the params cannot be queried.
+func (r *Reporter) getBondDenom(height int64) (string, error) {
+	if r.chain.AssetName != "" {
+		return types.GetBaseNativeDenom(r.chain.AssetName)
+	}
+
+	ctx := utils.GetHeightRequestContext(context.Background(), height)
+
+	res, err := r.stakingClient.Params(ctx, &stakingtypes.QueryParamsRequest{})
+	if err != nil {
+		log.Warn().Str("chain", r.chain.Name).Int64("height", height).Err(err).
+			Msg("error while getting staking params, using assets list")
+		return types.GetBaseNativeDenom(r.chain.Name)
+	}
+
+	return res.Params.BondDenom, nil
+}
+
 func (r *Reporter) getBalanceAmount(address string, height int64) (sdk.Coins, error) {
 	log.Debug().Str("chain", r.chain.Name).Int64("height", height).Msg("getting balance amount")
 
-	ctx := utils.GetRequestContext(height, r.grpcHeaders)
+	ctx := utils.GetHeightRequestContext(context.Background(), height)
 
 	balance := sdk.NewCoins()
 	var nextKey []byte
@@ -44,7 +69,7 @@ func (r *Reporter) getBalanceAmount(address string, height int64) (sdk.Coins, er
 func (r *Reporter) getDelegationsAmount(address string, height int64) (sdk.Coins, error) {
 	log.Debug().Str("chain", r.chain.Name).Int64("height", height).Msg("getting delegations amount")
 
-	ctx := utils.GetRequestContext(height, r.grpcHeaders)
+	ctx := utils.GetHeightRequestContext(context.Background(), height)
 
 	var delegations []stakingtypes.DelegationResponse
 	var nextKey []byte
@@ -79,7 +104,7 @@ func (r *Reporter) getDelegationsAmount(address string, height int64) (sdk.Coins
 func (r *Reporter) getReDelegationsAmount(address string, bondDenom string, height int64) (sdk.Coins, error) {
 	log.Debug().Str("chain", r.chain.Name).Int64("height", height).Msg("getting redelegations amount")
 
-	ctx := utils.GetRequestContext(height, r.grpcHeaders)
+	ctx := utils.GetHeightRequestContext(context.Background(), height)
 
 	var delegations []stakingtypes.RedelegationResponse
 	var nextKey []byte
@@ -113,7 +138,7 @@ func (r *Reporter) getReDelegationsAmount(address string, bondDenom string, heig
 func (r *Reporter) getUnbondingDelegationsAmount(address string, bondDenom string, height int64) (sdk.Coins, error) {
 	log.Debug().Str("chain", r.chain.Name).Int64("height", height).Msg("getting unbonding delegations amount")
 
-	ctx := utils.GetRequestContext(height, r.grpcHeaders)
+	ctx := utils.GetHeightRequestContext(context.Background(), height)
 
 	var delegations []stakingtypes.UnbondingDelegation
 	var nextKey []byte
@@ -144,17 +169,64 @@ func (r *Reporter) getUnbondingDelegationsAmount(address string, bondDenom strin
 	return amount, nil
 }
 
-func (r *Reporter) getOsmosisAmount(address string, height int64) (sdk.Coins, error) {
-	// If not Osmosis, return immediately
-	if !strings.Contains(strings.ToLower(r.chain.Name), "osmosis") {
+func (r *Reporter) getCW20Amount(address string, height int64) (sdk.Coins, error) {
+	if len(r.chain.CW20Tokens) == 0 {
 		return nil, nil
 	}
 
-	reporter, err := osmosis.NewReporter(r.grpcConnection, r.grpcHeaders, r.cdc)
+	log.Debug().Str("chain", r.chain.Name).Int64("height", height).Msg("getting cw20 amount")
+
+	ctx := utils.GetHeightRequestContext(context.Background(), height)
+
+	queryData, err := json.Marshal(types.NewCW20BalanceQuery(address))
 	if err != nil {
 		return nil, err
 	}
 
-	// Get the amount
-	return reporter.GetAmount(address, height)
+	amount := sdk.NewCoins()
+	for _, token := range r.chain.CW20Tokens {
+		res, err := r.wasmClient.SmartContractState(ctx, &wasmtypes.QuerySmartContractStateRequest{
+			Address:   token.Contract,
+			QueryData: queryData,
+		})
+		if err != nil {
+			if strings.Contains(err.Error(), "no such contract") {
+				// The contract did not exist at this height, so there's no balance
+				continue
+			}
+			return nil, fmt.Errorf("error while querying contract %s: %w", token.Contract, err)
+		}
+
+		var balance types.CW20BalanceResponse
+		err = json.Unmarshal(res.Data, &balance)
+		if err != nil {
+			return nil, fmt.Errorf("error while parsing balance of contract %s: %w", token.Contract, err)
+		}
+
+		balanceAmount, err := balance.GetAmount()
+		if err != nil {
+			return nil, err
+		}
+
+		amount = amount.Add(sdk.NewCoin(token.GetDenom(), balanceAmount))
+	}
+
+	return amount, nil
+}
+
+func (r *Reporter) getRewardsAmount(address string, height int64) (sdk.Coins, error) {
+	log.Debug().Str("chain", r.chain.Name).Int64("height", height).Msg("getting rewards amount")
+
+	ctx := utils.GetHeightRequestContext(context.Background(), height)
+
+	res, err := r.distributionClient.DelegationTotalRewards(ctx, &distrtypes.QueryDelegationTotalRewardsRequest{
+		DelegatorAddress: address,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The rewards are stored as decimal coins, but only the integer part can be withdrawn
+	amount, _ := res.Total.TruncateDecimal()
+	return amount, nil
 }
@@ -3,7 +3,8 @@ package reporter
 import (
 	"errors"
 	"fmt"
-	"strings"
+	"regexp"
+	"strconv"
 	"time"
 
 	"github.com/riccardom/briatore/types"
@@ -14,26 +15,27 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-// getBlockNearTimestamp returns the block nearest the given timestamp.
-// To do this we use the binary search between the genesis height and the latest block time.
-func (r *Reporter) getBlockNearTimestamp(timestamp time.Time) (types.BlockData, error) {
-	blockData, found, err := types.GetBlockData(r.chain.Name, timestamp)
+// GetBlockNearTimestamp returns the block near the given timestamp, chosen based on the configured policy.
+// To do this we search between the genesis height and the latest block time.
+func (r *Reporter) GetBlockNearTimestamp(timestamp time.Time, cfg *types.ReportConfig) (types.BlockData, error) {
+	policy := cfg.GetBlockPolicy()
+	blockData, found, err := types.GetBlockData(r.chain.Name, timestamp, policy)
 	if err != nil {
 		return types.BlockData{}, err
 	}
 
 	if !found {
-		block, err := r.getBlockNearTimestampFromChain(timestamp)
+		header, err := r.getBlockNearTimestampFromChain(timestamp, cfg.GetBlockSearchStrategy(), policy)
 		if err != nil {
 			return types.BlockData{}, err
 		}
 
-		if block == nil {
+		if header == nil {
 			// The chain didn't exist at that time, so we just return an empty balance report
 			return types.BlockData{}, nil
 		}
 
-		blockData = types.NewBlockData(r.chain.Name, block.Height, block.Time)
+		blockData = types.NewBlockData(r.chain.Name, header.Height, header.Time, policy, timestamp)
 
 		// Cache the blocks data
 		err = types.CacheBlockData(blockData)
@@ -45,163 +47,292 @@ func (r *Reporter) getBlockNearTimestamp(timestamp time.Time) (types.BlockData,
 	return blockData, nil
 }
 
-// getBlockNearTimestampFromChain returns the block nearest the given timestamp querying the chain.
-// To do this we use the binary search between the genesis height and the latest block time.
-func (r *Reporter) getBlockNearTimestampFromChain(timestamp time.Time) (*tmtypes.Block, error) {
-	log.Debug().Str("chain", r.chain.Name).Time("timestamp", timestamp).Msg("getting block near timestamp from chain")
+// getBlockNearTimestampFromChain returns the header of the block near the given timestamp querying the chain.
+// To do this we search between the genesis height and the latest block time using the given strategy,
+// and then choose among the closest blocks based on the given policy.
+// If no block satisfies the policy because the chain didn't exist at that time, nil is returned instead.
+func (r *Reporter) getBlockNearTimestampFromChain(
+	timestamp time.Time, strategy types.BlockSearchStrategy, policy types.BlockPolicy,
+) (*tmtypes.Header, error) {
+	log.Debug().Str("chain", r.chain.Name).Time("timestamp", timestamp).
+		Str("strategy", string(strategy)).Str("policy", string(policy)).
+		Msg("getting block near timestamp from chain")
+
+	minBlockHeight, err := r.getStartHeight()
+	if err != nil {
+		return nil, err
+	}
 
-	minBlockHeight := r.chain.MinBlockHeight
-	if minBlockHeight == 0 {
-		minHeight, err := r.client.MinHeight()
-		if err != nil {
-			return nil, fmt.Errorf("error while getting the genesis: %w", err)
-		}
-		minBlockHeight = minHeight
+	maxBlockHeight, err := r.getEndHeight()
+	if err != nil {
+		return nil, err
 	}
 
-	maxBlockHeight, err := r.client.LatestHeight()
+	search := &blockSearch{reporter: r, timestamp: timestamp}
+
+	var before, after *tmtypes.Header
+	switch strategy {
+	case types.BlockSearchBinary:
+		before, after, err = search.binarySearch(minBlockHeight, maxBlockHeight)
+	default:
+		before, after, err = search.interpolationSearch(minBlockHeight, maxBlockHeight)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("error while getting latest height: %w", err)
+		return nil, err
 	}
 
-	// Perform the binary search
-	block, err := r.binarySearchBlock(minBlockHeight, maxBlockHeight, timestamp)
+	header, err := search.selectHeader(before, after, policy)
 	if err != nil {
 		return nil, err
 	}
 
-	log.Debug().Str("chain", r.chain.Name).Time("timestamp", timestamp).Msgf("found block near timestamp: %d", block.Height)
+	if header != nil {
+		log.Debug().Str("chain", r.chain.Name).Time("timestamp", timestamp).Int("queries", search.queries).
+			Msgf("found block near timestamp: %d", header.Height)
+	}
 
-	return block, nil
+	return header, nil
 }
 
-// binarySearchBlock performs a binary search between the given min and max heights,
-// searching for the block that is closer to the given timestamp
-func (r *Reporter) binarySearchBlock(minHeight, maxHeight int64, timestamp time.Time) (*tmtypes.Block, error) {
-	log.Trace().Int64("min height", minHeight).Int64("max height", maxHeight).Time("timestamp", timestamp).Msg("binary search")
+// --------------------------------------------------------------------------------------------------------------------
 
-	// Get the min block available or the genesis one if the given min height is not found
-	minBlock, err := r.getBlockOrMinHeight(minHeight)
-	if err != nil {
-		return nil, fmt.Errorf("error while getting min block: %w", err)
-	}
-	minHeight = minBlock.Height
+// blockSearch contains the data of a single search of the blocks closest to a timestamp,
+// keeping track of the number of queries that have been performed.
+// Each search returns the last block before and the first block after the timestamp,
+// which are the same block if its timestamp matches exactly, or nil if such block does not exist.
+type blockSearch struct {
+	reporter  *Reporter
+	timestamp time.Time
+	queries   int
+}
 
-	if minBlock.Time.Equal(timestamp) || minBlock.Time.After(timestamp) {
-		// The min block has a timestamp that is equals or after the given timestamp, so we can return this
-		return minBlock, nil
+// interpolationSearch searches between the given min and max heights for the blocks that are closer to the timestamp,
+// estimating the target height from the average block time between the current bounds.
+// When the estimates do not shrink the search interval enough (e.g. due to a chain halt), bisection is used instead.
+func (s *blockSearch) interpolationSearch(minHeight, maxHeight int64) (before, after *tmtypes.Header, err error) {
+	before, after, err = s.getBounds(minHeight, maxHeight)
+	if err != nil || before == nil || after == nil {
+		return before, after, err
 	}
 
-	// Get the max block available or the latest one if the given max height is not found
-	maxBlock, err := r.getBlockOrLatestHeight(maxHeight)
-	if err != nil {
-		return nil, fmt.Errorf("error while gettingwmax block")
-	}
-	maxHeight = maxBlock.Height
+	bisect := false
+	for after.Height-before.Height > 1 {
+		size := after.Height - before.Height
+
+		var height int64
+		if bisect {
+			height = (before.Height + after.Height) / 2
+		} else {
+			avgBlockTime := after.Time.Sub(before.Time) / time.Duration(size)
+			height = before.Height + int64(s.timestamp.Sub(before.Time)/avgBlockTime)
+		}
+
+		// Make sure the estimated height is strictly within the current bounds
+		height = max(before.Height+1, min(height, after.Height-1))
+
+		log.Trace().Int64("min height", before.Height).Int64("max height", after.Height).
+			Int64("height", height).Bool("bisect", bisect).Time("timestamp", s.timestamp).Msg("interpolation search")
 
-	if maxBlock.Time.Equal(timestamp) || maxBlock.Time.Before(timestamp) {
-		// The max block has the timestamp equals or before the given timestamp, so we can end the research
-		return maxBlock, nil
+		header, err := s.getHeaderOrMinHeight(height)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error while getting estimated block: %w", err)
+		}
+
+		if header.Time.Equal(s.timestamp) {
+			return header, header, nil
+		}
+
+		if header.Time.Before(s.timestamp) {
+			before = header
+		} else {
+			after = header
+		}
+
+		// If the estimate did not halve the interval, use bisection for the next step to guarantee the convergence
+		bisect = !bisect && after.Height-before.Height > size/2
 	}
 
-	if maxBlock.Height-minBlock.Height == 0 {
-		// If the min block and max block have the same heights, return either one of the two
-		return minBlock, nil
+	return before, after, nil
+}
+
+// binarySearch performs a binary search between the given min and max heights,
+// searching for the blocks that are closer to the timestamp
+func (s *blockSearch) binarySearch(minHeight, maxHeight int64) (before, after *tmtypes.Header, err error) {
+	before, after, err = s.getBounds(minHeight, maxHeight)
+	if err != nil || before == nil || after == nil {
+		return before, after, err
 	}
 
-	if maxBlock.Height-minBlock.Height == 1 {
-		// If the min block is before, and the max block is after the timestamp we just return the min block
-		if minBlock.Time.Before(timestamp) && maxBlock.Time.After(timestamp) {
-			return minBlock, nil
+	for after.Height-before.Height > 1 {
+		log.Trace().Int64("min height", before.Height).Int64("max height", after.Height).
+			Time("timestamp", s.timestamp).Msg("binary search")
+
+		avgHeader, err := s.getHeaderOrMinHeight((before.Height + after.Height) / 2)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error while getting average block: %w", err)
 		}
 
-		// We've reached the point where we only have two blocks.
-		// Now we need to find the one that is closer to the searched timestamp
-		minDiff := timestamp.Sub(minBlock.Time)
-		maxDiff := maxBlock.Time.Sub(timestamp)
+		if avgHeader.Time.Equal(s.timestamp) {
+			// The average block has the same timestamp as the one searched for
+			return avgHeader, avgHeader, nil
+		}
 
-		if minDiff < maxDiff {
-			// The min block is closer to the timestamp than the max block
-			return minBlock, nil
+		if avgHeader.Time.After(s.timestamp) {
+			// If the average block has the timestamp after the searched value, it means the searched
+			// value is in between the min value and the average one
+			after = avgHeader
+		} else {
+			// If the average block has the timestamp before the searched value, it means the searched
+			// value is in between the average block and the max height
+			before = avgHeader
 		}
+	}
+
+	return before, after, nil
+}
 
-		// The max block is closer to the timestamp than the min block
-		return maxBlock, nil
+// getBounds returns the headers of the blocks at the given min and max heights.
+// If the timestamp is outside the bounds, only the header of the closest bound is returned.
+func (s *blockSearch) getBounds(minHeight, maxHeight int64) (before, after *tmtypes.Header, err error) {
+	// Get the min block available or the genesis one if the given min height is not found
+	minHeader, err := s.getHeaderOrMinHeight(minHeight)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while getting min block: %w", err)
 	}
 
-	avgHeight := (maxHeight + minHeight) / 2
+	if minHeader.Time.Equal(s.timestamp) {
+		return minHeader, minHeader, nil
+	}
+
+	if minHeader.Time.After(s.timestamp) {
+		// The min block has a timestamp that is after the given timestamp, so there are no blocks before it
+		return nil, minHeader, nil
+	}
 
-	avgBlock, err := r.getBlockOrMinHeight(avgHeight)
+	// Get the max block available or the latest one if the given max height is not found
+	maxHeader, err := s.getHeaderOrLatestHeight(maxHeight)
 	if err != nil {
-		return nil, fmt.Errorf("error while getting average block: %w", err)
+		return nil, nil, fmt.Errorf("error while getting max block: %w", err)
 	}
 
-	if avgBlock.Time.Equal(timestamp) {
-		// The average block has the same timestamp as the one searched for
-		return avgBlock, nil
+	if maxHeader.Time.Equal(s.timestamp) {
+		return maxHeader, maxHeader, nil
 	}
 
-	if avgBlock.Time.After(timestamp) {
-		// If the average block has the timestamp after the searched value, it means the searched
-		// value is in between the min value and the average one
-		maxHeight = avgBlock.Height
+	if maxHeader.Time.Before(s.timestamp) {
+		// The max block has a timestamp that is before the given timestamp, so there are no blocks after it
+		return maxHeader, nil, nil
 	}
 
-	if avgBlock.Time.Before(timestamp) {
-		// If the average block has the timestamp before the searched value, it means the searched
-		// value is in between the average block and the max height
-		minHeight = avgBlock.Height
+	return minHeader, maxHeader, nil
+}
+
+// selectHeader returns the header to be used among the given ones, which are the last block before and the
+// first block after the timestamp, based on the provided policy
+func (s *blockSearch) selectHeader(before, after *tmtypes.Header, policy types.BlockPolicy) (*tmtypes.Header, error) {
+	switch policy {
+	case types.BlockPolicyLastBefore:
+		// If there is no block before the timestamp, the chain didn't exist at that time
+		return before, nil
+
+	case types.BlockPolicyFirstAfter:
+		if after == nil {
+			return nil, fmt.Errorf("no block has been produced after %s yet", s.timestamp)
+		}
+		return after, nil
+
+	case types.BlockPolicyNearest:
+		if before == nil {
+			return after, nil
+		}
+
+		if after == nil {
+			return before, nil
+		}
+
+		// Find the one that is closer to the searched timestamp, preferring the block before in case of a tie
+		if s.timestamp.Sub(before.Time) <= after.Time.Sub(s.timestamp) {
+			return before, nil
+		}
+		return after, nil
+
+	default:
+		return nil, fmt.Errorf("invalid block policy value: %s", policy)
 	}
+}
 
-	return r.binarySearchBlock(minHeight, maxHeight, timestamp)
+// getHeader gets the header of the block at the given height, keeping track of the number of queries performed
+func (s *blockSearch) getHeader(height int64) (*tmtypes.Header, error) {
+	s.queries++
+	return s.reporter.client.Header(height)
 }
 
-// getBlockOrMinHeight gets the block at the given height, or the min height available if not found
-func (r *Reporter) getBlockOrMinHeight(height int64) (*tmtypes.Block, error) {
-	block, err := r.client.Block(height)
+// getHeaderOrMinHeight gets the header of the block at the given height, or the min height available if not found
+func (s *blockSearch) getHeaderOrMinHeight(height int64) (*tmtypes.Header, error) {
+	header, err := s.getHeader(height)
 
 	if err != nil {
-		if rpcErr, ok := errors.Unwrap(err).(*rpctypes.RPCError); ok && strings.Contains(rpcErr.Data, "lowest height") {
-			var lowestHeight int64
-			_, err = fmt.Sscanf(rpcErr.Data, "height %d is not available, lowest height is %d", &height, &lowestHeight)
-			if err != nil {
-				return nil, err
-			}
-
-			log.Debug().Str("chain", r.chain.Name).
-				Int64("height", height).Int64("lowest height", lowestHeight).
+		if minHeight, _, ok := parseHeightRangeError(err); ok && minHeight > height {
+			log.Debug().Str("chain", s.reporter.chain.Name).
+				Int64("height", height).Int64("lowest height", minHeight).
 				Msg("height not found, getting lowest height")
 
-			return r.client.Block(lowestHeight)
+			return s.getHeader(minHeight)
 		}
 
 		return nil, err
 	}
 
-	return block, nil
+	return header, nil
 }
 
-// getBlockOrLatestHeight gets the block at the given height, or the max height available if not found
-func (r *Reporter) getBlockOrLatestHeight(height int64) (*tmtypes.Block, error) {
-	block, err := r.client.Block(height)
+// getHeaderOrLatestHeight gets the header of the block at the given height, or the max height available if not found
+func (s *blockSearch) getHeaderOrLatestHeight(height int64) (*tmtypes.Header, error) {
+	header, err := s.getHeader(height)
 
 	if err != nil {
-		if rpcErr, ok := errors.Unwrap(err).(*rpctypes.RPCError); ok && strings.Contains(rpcErr.Data, "current blockchain height") {
-			var maxHeight int64
-			_, err = fmt.Sscanf(rpcErr.Data, "height %d must be less than or equal to the current blockchain height %d", &height, &maxHeight)
-			if err != nil {
-				return nil, err
-			}
-
-			log.Debug().Str("chain", r.chain.Name).
+		if _, maxHeight, ok := parseHeightRangeError(err); ok && maxHeight < height {
+			log.Debug().Str("chain", s.reporter.chain.Name).
 				Int64("height", height).Int64("max height", maxHeight).
 				Msg("height not found, getting max height")
 
-			return r.client.Block(maxHeight)
+			return s.getHeader(maxHeight)
 		}
 
 		return nil, err
 	}
 
-	return block, nil
+	return header, nil
+}
+
+// heightRangeErrorRegex matches the message returned by the node when querying a height that is outside
+// the range of available blocks, which can be found anywhere inside the error (e.g. after an "invalid request" prefix)
+var heightRangeErrorRegex = regexp.MustCompile(`min height (\d+) can't be greater than max height (\d+)`)
+
+// parseHeightRangeError parses the error returned by the node when querying a height that is outside
+// the range of available blocks, returning the heights that the node would have used as bounds
+func parseHeightRangeError(err error) (minHeight, maxHeight int64, ok bool) {
+	message := err.Error()
+
+	var rpcErr *rpctypes.RPCError
+	if errors.As(err, &rpcErr) {
+		message = rpcErr.Data + " " + rpcErr.Message
+	}
+
+	matches := heightRangeErrorRegex.FindStringSubmatch(message)
+	if matches == nil {
+		return 0, 0, false
+	}
+
+	minHeight, err = strconv.ParseInt(matches[1], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	maxHeight, err = strconv.ParseInt(matches[2], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return minHeight, maxHeight, true
 }
@@ -0,0 +1,328 @@
+package reporter
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	rpctypes "github.com/cometbft/cometbft/rpc/jsonrpc/types"
+	tmtypes "github.com/cometbft/cometbft/types"
+
+	"github.com/riccardom/briatore/types"
+)
+
+var (
+	testGenesisTime = time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// fakeClient is a CosmosClient that serves blocks produced every 6 seconds, with a one hour halt after the
+// halt height, and that returns the same errors of a node when querying heights outside the available ones
+type fakeClient struct {
+	baseHeight   int64
+	latestHeight int64
+	haltHeight   int64
+}
+
+func (c *fakeClient) MinHeight() (int64, error) {
+	return c.baseHeight, nil
+}
+
+func (c *fakeClient) LatestHeight() (int64, error) {
+	return c.latestHeight, nil
+}
+
+func (c *fakeClient) Header(height int64) (*tmtypes.Header, error) {
+	if height < c.baseHeight || height > c.latestHeight {
+		return nil, fmt.Errorf("error in json rpc client, with http response metadata: %w", &rpctypes.RPCError{
+			Code:    -32603,
+			Message: "Internal error",
+			Data:    fmt.Sprintf("min height %d can't be greater than max height %d", max(height, c.baseHeight), min(height, c.latestHeight)),
+		})
+	}
+
+	return &tmtypes.Header{Height: height, Time: c.blockTime(height)}, nil
+}
+
+// blockTime returns the time of the block at the given height
+func (c *fakeClient) blockTime(height int64) time.Time {
+	blockTime := testGenesisTime.Add(time.Duration(height-1) * 6 * time.Second)
+	if c.haltHeight != 0 && height > c.haltHeight {
+		blockTime = blockTime.Add(time.Hour)
+	}
+	return blockTime
+}
+
+func newTestBlockSearch(client *fakeClient, timestamp time.Time) *blockSearch {
+	return &blockSearch{
+		reporter: &Reporter{
+			chain:  &types.ChainConfig{Name: "test"},
+			client: client,
+		},
+		timestamp: timestamp,
+	}
+}
+
+func TestBlockSearch(t *testing.T) {
+	client := &fakeClient{baseHeight: 1, latestHeight: 1000, haltHeight: 500}
+	prunedClient := &fakeClient{baseHeight: 50, latestHeight: 1000}
+
+	testCases := []struct {
+		name           string
+		client         *fakeClient
+		timestamp      time.Time
+		minHeight      int64
+		maxHeight      int64
+		expectedBefore int64
+		expectedAfter  int64
+	}{
+		{
+			name:           "exact block timestamp",
+			client:         client,
+			timestamp:      client.blockTime(100),
+			minHeight:      1,
+			maxHeight:      1000,
+			expectedBefore: 100,
+			expectedAfter:  100,
+		},
+		{
+			name:           "timestamp between two blocks",
+			client:         client,
+			timestamp:      client.blockTime(100).Add(3 * time.Second),
+			minHeight:      1,
+			maxHeight:      1000,
+			expectedBefore: 100,
+			expectedAfter:  101,
+		},
+		{
+			name:           "timestamp during a chain halt",
+			client:         client,
+			timestamp:      client.blockTime(500).Add(30 * time.Minute),
+			minHeight:      1,
+			maxHeight:      1000,
+			expectedBefore: 500,
+			expectedAfter:  501,
+		},
+		{
+			name:           "timestamp after the halt",
+			client:         client,
+			timestamp:      client.blockTime(900).Add(time.Second),
+			minHeight:      1,
+			maxHeight:      1000,
+			expectedBefore: 900,
+			expectedAfter:  901,
+		},
+		{
+			name:          "timestamp before genesis",
+			client:        client,
+			timestamp:     testGenesisTime.Add(-time.Hour),
+			minHeight:     1,
+			maxHeight:     1000,
+			expectedAfter: 1,
+		},
+		{
+			name:           "timestamp after the latest block",
+			client:         client,
+			timestamp:      client.blockTime(1000).Add(time.Hour),
+			minHeight:      1,
+			maxHeight:      1000,
+			expectedBefore: 1000,
+		},
+		{
+			name:           "max height after the latest block",
+			client:         client,
+			timestamp:      client.blockTime(700).Add(time.Second),
+			minHeight:      1,
+			maxHeight:      2000,
+			expectedBefore: 700,
+			expectedAfter:  701,
+		},
+		{
+			name:          "timestamp before the lowest available block",
+			client:        prunedClient,
+			timestamp:     prunedClient.blockTime(20),
+			minHeight:     1,
+			maxHeight:     1000,
+			expectedAfter: 50,
+		},
+		{
+			name:           "min height before the lowest available block",
+			client:         prunedClient,
+			timestamp:      prunedClient.blockTime(300).Add(time.Second),
+			minHeight:      1,
+			maxHeight:      1000,
+			expectedBefore: 300,
+			expectedAfter:  301,
+		},
+	}
+
+	strategies := map[string]func(s *blockSearch, minHeight, maxHeight int64) (before, after *tmtypes.Header, err error){
+		"interpolation": (*blockSearch).interpolationSearch,
+		"binary":        (*blockSearch).binarySearch,
+	}
+
+	for strategy, search := range strategies {
+		for _, tc := range testCases {
+			t.Run(fmt.Sprintf("%s/%s", strategy, tc.name), func(t *testing.T) {
+				before, after, err := search(newTestBlockSearch(tc.client, tc.timestamp), tc.minHeight, tc.maxHeight)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+
+				if height := getHeaderHeight(before); height != tc.expectedBefore {
+					t.Errorf("expected block before at height %d, got %d", tc.expectedBefore, height)
+				}
+				if height := getHeaderHeight(after); height != tc.expectedAfter {
+					t.Errorf("expected block after at height %d, got %d", tc.expectedAfter, height)
+				}
+			})
+		}
+	}
+}
+
+func TestBlockSearch_InterpolationQueries(t *testing.T) {
+	client := &fakeClient{baseHeight: 1, latestHeight: 1_000_000}
+	timestamp := client.blockTime(123_456).Add(time.Second)
+
+	interpolation := newTestBlockSearch(client, timestamp)
+	_, _, err := interpolation.interpolationSearch(1, client.latestHeight)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	binary := newTestBlockSearch(client, timestamp)
+	_, _, err = binary.binarySearch(1, client.latestHeight)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if interpolation.queries >= binary.queries {
+		t.Errorf("expected interpolation search to perform less queries than binary search, got %d and %d",
+			interpolation.queries, binary.queries)
+	}
+}
+
+func TestBlockSearch_SelectHeader(t *testing.T) {
+	timestamp := testGenesisTime.Add(time.Minute)
+	before := &tmtypes.Header{Height: 10, Time: timestamp.Add(-2 * time.Second)}
+	after := &tmtypes.Header{Height: 11, Time: timestamp.Add(4 * time.Second)}
+	tieAfter := &tmtypes.Header{Height: 11, Time: timestamp.Add(2 * time.Second)}
+	closerAfter := &tmtypes.Header{Height: 11, Time: timestamp.Add(time.Second)}
+
+	testCases := []struct {
+		name           string
+		policy         types.BlockPolicy
+		before         *tmtypes.Header
+		after          *tmtypes.Header
+		shouldErr      bool
+		expectedHeight int64
+	}{
+		{name: "last before returns the block before", policy: types.BlockPolicyLastBefore, before: before, after: after, expectedHeight: 10},
+		{name: "last before without block before returns nil", policy: types.BlockPolicyLastBefore, after: after},
+		{name: "first after returns the block after", policy: types.BlockPolicyFirstAfter, before: before, after: after, expectedHeight: 11},
+		{name: "first after without block after errors", policy: types.BlockPolicyFirstAfter, before: before, shouldErr: true},
+		{name: "nearest returns the closer block", policy: types.BlockPolicyNearest, before: before, after: closerAfter, expectedHeight: 11},
+		{name: "nearest prefers the block before on ties", policy: types.BlockPolicyNearest, before: before, after: tieAfter, expectedHeight: 10},
+		{name: "nearest without block before returns the block after", policy: types.BlockPolicyNearest, after: after, expectedHeight: 11},
+		{name: "nearest without block after returns the block before", policy: types.BlockPolicyNearest, before: before, expectedHeight: 10},
+		{name: "invalid policy errors", policy: "invalid", before: before, after: after, shouldErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			search := newTestBlockSearch(&fakeClient{}, timestamp)
+			header, err := search.selectHeader(tc.before, tc.after, tc.policy)
+			if tc.shouldErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if height := getHeaderHeight(header); height != tc.expectedHeight {
+				t.Errorf("expected block at height %d, got %d", tc.expectedHeight, height)
+			}
+		})
+	}
+}
+
+func TestParseHeightRangeError(t *testing.T) {
+	testCases := []struct {
+		name              string
+		err               error
+		expectedOk        bool
+		expectedMinHeight int64
+		expectedMaxHeight int64
+	}{
+		{
+			name: "message inside the error data",
+			err: fmt.Errorf("response error: %w", &rpctypes.RPCError{
+				Code:    -32603,
+				Message: "Internal error",
+				Data:    "min height 50 can't be greater than max height 1",
+			}),
+			expectedOk:        true,
+			expectedMinHeight: 50,
+			expectedMaxHeight: 1,
+		},
+		{
+			name: "message with a prefix inside the error data",
+			err: fmt.Errorf("response error: %w", &rpctypes.RPCError{
+				Code:    -32603,
+				Message: "Internal error",
+				Data:    "invalid request: min height 2000 can't be greater than max height 1000",
+			}),
+			expectedOk:        true,
+			expectedMinHeight: 2000,
+			expectedMaxHeight: 1000,
+		},
+		{
+			name: "message inside the error message",
+			err: fmt.Errorf("response error: %w", &rpctypes.RPCError{
+				Code:    -32600,
+				Message: "invalid request: min height 50 can't be greater than max height 1",
+			}),
+			expectedOk:        true,
+			expectedMinHeight: 50,
+			expectedMaxHeight: 1,
+		},
+		{
+			name:              "plain error",
+			err:               fmt.Errorf("error while querying: min height 7 can't be greater than max height 3"),
+			expectedOk:        true,
+			expectedMinHeight: 7,
+			expectedMaxHeight: 3,
+		},
+		{
+			name: "unrelated error",
+			err: fmt.Errorf("response error: %w", &rpctypes.RPCError{
+				Code:    -32603,
+				Message: "Internal error",
+				Data:    "height 10 is not available, lowest height is 50",
+			}),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			minHeight, maxHeight, ok := parseHeightRangeError(tc.err)
+			if ok != tc.expectedOk {
+				t.Fatalf("expected ok %t, got %t", tc.expectedOk, ok)
+			}
+			if minHeight != tc.expectedMinHeight || maxHeight != tc.expectedMaxHeight {
+				t.Errorf("expected heights %d-%d, got %d-%d",
+					tc.expectedMinHeight, tc.expectedMaxHeight, minHeight, maxHeight)
+			}
+		})
+	}
+}
+
+// getHeaderHeight returns the height of the given header, or 0 if nil
+func getHeaderHeight(header *tmtypes.Header) int64 {
+	if header == nil {
+		return 0
+	}
+	return header.Height
+}
@@ -0,0 +1,58 @@
+package reporter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	ibctransfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/riccardom/briatore/types"
+	"github.com/riccardom/briatore/utils"
+)
+
+const (
+	ibcDenomPrefix = "ibc/"
+)
+
+// getAssetByCoinDenom returns the asset associated with the given coin denom.
+// If the denom is an IBC denom that is not part of the assets list, its denom trace is queried at the given height
+// and the asset is searched using the base denom instead.
+// If the denom trace cannot be read, the error is logged and the asset is considered not found.
+func (r *Reporter) getAssetByCoinDenom(assets types.Assets, denom string, height int64) (asset *types.Asset, found bool) {
+	asset, found = assets.GetAssetByCoinDenom(denom)
+	if found || !strings.HasPrefix(denom, ibcDenomPrefix) {
+		return asset, found
+	}
+
+	baseDenom, err := r.getIBCBaseDenom(denom, height)
+	if err != nil {
+		log.Warn().Str("chain", r.chain.Name).Str("denom", denom).Int64("height", height).Err(err).
+			Msg("error while getting denom trace, skipping denom")
+		return nil, false
+	}
+
+	log.Trace().Str("chain", r.chain.Name).Str("denom", denom).Str("base denom", baseDenom).Msg("resolved ibc denom")
+
+	return assets.GetAssetByCoinDenom(baseDenom)
+}
+
+// getIBCBaseDenom returns the base denom of the given IBC denom using the denom trace stored on chain
+func (r *Reporter) getIBCBaseDenom(denom string, height int64) (string, error) {
+	ctx := utils.GetHeightRequestContext(context.Background(), height)
+
+	// Older versions of IBC only support the hash, so we strip the prefix from the denom
+	res, err := r.transferClient.DenomTrace(ctx, &ibctransfertypes.QueryDenomTraceRequest{
+		Hash: strings.TrimPrefix(denom, ibcDenomPrefix),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if res.DenomTrace == nil {
+		return "", fmt.Errorf("denom trace not found")
+	}
+
+	return res.DenomTrace.BaseDenom, nil
+}
@@ -0,0 +1,287 @@
+package reporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	tmtypes "github.com/cometbft/cometbft/types"
+	"github.com/cosmos/cosmos-sdk/codec"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/riccardom/briatore/cosmos"
+	"github.com/riccardom/briatore/gprc"
+	"github.com/riccardom/briatore/utils"
+)
+
+var (
+	_ grpc.ClientConnInterface = &EndpointsRouter{}
+	_ CosmosClient             = &EndpointsRouter{}
+)
+
+// endpoint contains the clients used to query a single RPC endpoint of a chain
+type endpoint struct {
+	address    string
+	headers    map[string]string
+	connection *gprc.Connection
+	client     *cosmos.Client
+
+	// minHeight is the earliest height for which the endpoint has the chain data.
+	// It is refreshed when the endpoint no longer has the data of a height, since nodes keep pruning old blocks.
+	minHeightMutex sync.RWMutex
+	minHeight      int64
+}
+
+// newEndpoint builds a new endpoint for the given RPC address, probing the earliest height available on it
+func newEndpoint(rpcAddress string, cdc codec.Codec) (*endpoint, error) {
+	// Try pinging the address
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	if err := utils.PingAddress(rpcAddress, httpClient); err != nil {
+		return nil, fmt.Errorf("error while pinging the RPC address: %w", err)
+	}
+
+	address, headers := utils.ParseAddressHeaders(rpcAddress)
+	grpcConnection, err := gprc.NewConnection(address, cdc)
+	if err != nil {
+		return nil, err
+	}
+
+	cosmosClient, err := cosmos.NewClient(rpcAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	minHeight, err := cosmosClient.MinHeight()
+	if err != nil {
+		_ = cosmosClient.Stop()
+		return nil, fmt.Errorf("error while getting the earliest height: %w", err)
+	}
+
+	return &endpoint{
+		address:    address,
+		headers:    headers,
+		connection: grpcConnection,
+		client:     cosmosClient,
+		minHeight:  minHeight,
+	}, nil
+}
+
+// stop stops the clients of the endpoint
+func (e *endpoint) stop() error {
+	return e.client.Stop()
+}
+
+// getMinHeight returns the earliest height for which the endpoint has the chain data
+func (e *endpoint) getMinHeight() int64 {
+	e.minHeightMutex.RLock()
+	defer e.minHeightMutex.RUnlock()
+	return e.minHeight
+}
+
+// refreshMinHeight reads again the earliest height for which the endpoint has the chain data
+func (e *endpoint) refreshMinHeight() (int64, error) {
+	minHeight, err := e.client.MinHeight()
+	if err != nil {
+		return 0, fmt.Errorf("error while getting the earliest height: %w", err)
+	}
+
+	e.minHeightMutex.Lock()
+	defer e.minHeightMutex.Unlock()
+	e.minHeight = minHeight
+	return minHeight, nil
+}
+
+// covers tells whether the endpoint has the chain data at the given height
+func (e *endpoint) covers(height int64) bool {
+	return height == 0 || e.getMinHeight() <= height
+}
+
+// unavailableHeightErrorRegex matches the messages returned by the nodes when querying a height whose data
+// is not available on them (e.g. because it has been pruned)
+var unavailableHeightErrorRegex = regexp.MustCompile(`lowest height is \d+|version does not exist|height \d+ is not available`)
+
+// isUnavailableHeightError tells whether the given error has been returned by a node that does not have the data
+// of the queried height
+func isUnavailableHeightError(err error) bool {
+	if _, _, ok := parseHeightRangeError(err); ok {
+		return true
+	}
+	return unavailableHeightErrorRegex.MatchString(err.Error())
+}
+
+// --------------------------------------------------------------------------------------------------------------------
+
+// EndpointsRouter routes each query of a chain to the endpoints having the data at the queried height,
+// failing over to the next one in case of errors. It also keeps track of the endpoints that served the data.
+type EndpointsRouter struct {
+	chainName string
+	endpoints []*endpoint
+
+	servedMutex sync.Mutex
+	served      map[string]bool
+}
+
+// NewEndpointsRouter returns a new EndpointsRouter for the given RPC addresses.
+// Addresses that cannot be reached are skipped, and an error is returned only if none of them is available.
+func NewEndpointsRouter(chainName string, rpcAddresses []string, cdc codec.Codec) (*EndpointsRouter, error) {
+	var endpoints []*endpoint
+	for _, rpcAddress := range rpcAddresses {
+		endpoint, err := newEndpoint(rpcAddress, cdc)
+		if err != nil {
+			log.Warn().Str("chain", chainName).Str("endpoint", rpcAddress).Err(err).Msg("skipping unavailable endpoint")
+			continue
+		}
+
+		log.Debug().Str("chain", chainName).Str("endpoint", endpoint.address).Int64("min height", endpoint.getMinHeight()).
+			Msg("endpoint available")
+		endpoints = append(endpoints, endpoint)
+	}
+
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no available endpoint for chain %s", chainName)
+	}
+
+	return &EndpointsRouter{
+		chainName: chainName,
+		endpoints: endpoints,
+		served:    map[string]bool{},
+	}, nil
+}
+
+// getEndpoints returns the endpoints that should be used to query the given height, in order of preference.
+// If no endpoint has the data at such height all of them are returned,
+// so that the errors returned by the nodes can be inspected by the caller.
+func (r *EndpointsRouter) getEndpoints(height int64) []*endpoint {
+	var endpoints []*endpoint
+	for _, endpoint := range r.endpoints {
+		if endpoint.covers(height) {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+
+	if len(endpoints) == 0 {
+		return r.endpoints
+	}
+
+	return endpoints
+}
+
+// route runs the given query on the endpoints that have the data at the provided height,
+// returning as soon as one of them succeeds.
+// Endpoints failing because they no longer have the data of the height get their earliest height refreshed,
+// so that they are not chosen again for heights they have pruned.
+// If all of them fail, the first error returned by a node is preferred to connection errors,
+// since it is the chain answer to the query (e.g. an entity not being found).
+func (r *EndpointsRouter) route(height int64, query func(endpoint *endpoint) error) error {
+	var queryErr error
+	for _, endpoint := range r.getEndpoints(height) {
+		err := query(endpoint)
+		if err == nil {
+			r.servedMutex.Lock()
+			r.served[endpoint.address] = true
+			r.servedMutex.Unlock()
+			return nil
+		}
+
+		log.Debug().Str("chain", r.chainName).Str("endpoint", endpoint.address).Int64("height", height).Err(err).
+			Msg("query failed, trying next endpoint")
+
+		if height != 0 && isUnavailableHeightError(err) {
+			r.refreshMinHeight(endpoint)
+		}
+
+		var statusErr interface{ GRPCStatus() *status.Status }
+		if queryErr == nil || (errors.As(err, &statusErr) && !errors.As(queryErr, &statusErr)) {
+			queryErr = err
+		}
+	}
+
+	return queryErr
+}
+
+// refreshMinHeight refreshes the earliest height of the given endpoint. Errors are only logged since the endpoint
+// keeps being used with its previous earliest height
+func (r *EndpointsRouter) refreshMinHeight(endpoint *endpoint) {
+	minHeight, err := endpoint.refreshMinHeight()
+	if err != nil {
+		log.Debug().Str("chain", r.chainName).Str("endpoint", endpoint.address).Err(err).
+			Msg("error while refreshing the earliest height")
+		return
+	}
+
+	log.Debug().Str("chain", r.chainName).Str("endpoint", endpoint.address).Int64("min height", minHeight).
+		Msg("earliest height refreshed")
+}
+
+// Stop stops the clients of all the endpoints. Errors are only logged since the router is not used anymore
+func (r *EndpointsRouter) Stop() {
+	for _, endpoint := range r.endpoints {
+		err := endpoint.stop()
+		if err != nil {
+			log.Debug().Str("chain", r.chainName).Str("endpoint", endpoint.address).Err(err).
+				Msg("error while stopping endpoint")
+		}
+	}
+}
+
+// GetServedEndpoints returns the addresses of the endpoints that have successfully served at least one query
+func (r *EndpointsRouter) GetServedEndpoints() []string {
+	r.servedMutex.Lock()
+	defer r.servedMutex.Unlock()
+
+	endpoints := make([]string, 0, len(r.served))
+	for address := range r.served {
+		endpoints = append(endpoints, address)
+	}
+	sort.Strings(endpoints)
+	return endpoints
+}
+
+// Invoke implements the grpc.ClientConnInterface interface
+func (r *EndpointsRouter) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	height, _ := gprc.BlockHeightFromOutgoingContext(ctx)
+	return r.route(height, func(endpoint *endpoint) error {
+		return endpoint.connection.Invoke(utils.ContextWithHeaders(ctx, endpoint.headers), method, args, reply, opts...)
+	})
+}
+
+// NewStream implements the grpc.ClientConnInterface interface
+func (r *EndpointsRouter) NewStream(_ context.Context, _ *grpc.StreamDesc, _ string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+// MinHeight implements the CosmosClient interface
+func (r *EndpointsRouter) MinHeight() (int64, error) {
+	minHeight := r.endpoints[0].getMinHeight()
+	for _, endpoint := range r.endpoints[1:] {
+		minHeight = min(minHeight, endpoint.getMinHeight())
+	}
+	return minHeight, nil
+}
+
+// LatestHeight implements the CosmosClient interface
+func (r *EndpointsRouter) LatestHeight() (int64, error) {
+	var height int64
+	err := r.route(0, func(endpoint *endpoint) (err error) {
+		height, err = endpoint.client.LatestHeight()
+		return err
+	})
+	return height, err
+}
+
+// Header implements the CosmosClient interface
+func (r *EndpointsRouter) Header(height int64) (*tmtypes.Header, error) {
+	var header *tmtypes.Header
+	err := r.route(height, func(endpoint *endpoint) (err error) {
+		header, err = endpoint.client.Header(height)
+		return err
+	})
+	return header, err
+}
@@ -0,0 +1,58 @@
+package reporter
+
+import (
+	"fmt"
+	"testing"
+
+	rpctypes "github.com/cometbft/cometbft/rpc/jsonrpc/types"
+)
+
+func TestIsUnavailableHeightError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name: "height range error",
+			err: fmt.Errorf("response error: %w", &rpctypes.RPCError{
+				Code:    -32603,
+				Message: "Internal error",
+				Data:    "min height 50 can't be greater than max height 1",
+			}),
+			expected: true,
+		},
+		{
+			name: "pruned block error",
+			err: fmt.Errorf("response error: %w", &rpctypes.RPCError{
+				Code:    -32603,
+				Message: "Internal error",
+				Data:    "height 10 is not available, lowest height is 50",
+			}),
+			expected: true,
+		},
+		{
+			name:     "pruned state error",
+			err:      fmt.Errorf("rpc error: code = InvalidArgument desc = failed to load state at height 10; version does not exist (latest height: 1000): invalid request"),
+			expected: true,
+		},
+		{
+			name:     "unrelated error",
+			err:      fmt.Errorf("rpc error: code = NotFound desc = delegation not found"),
+			expected: false,
+		},
+		{
+			name:     "connection error",
+			err:      fmt.Errorf("rpc error: code = Unavailable desc = connection error: service is not available"),
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := isUnavailableHeightError(tc.err); result != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package reporter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/riccardom/briatore/types"
+)
+
+// selectEraReporter returns the reporter of the era of the given chain containing the timestamp, getting the reporter
+// of each checked era using the given function.
+// Eras are checked starting from the most recent one, and the first one that started before the timestamp is used.
+// If the timestamp is before all the eras, the first one is used.
+func selectEraReporter(
+	cfg *types.ChainConfig, timestamp time.Time, getEraReporter func(index int) (*Reporter, error),
+) (*Reporter, error) {
+	eras := cfg.GetEras()
+	for i := len(eras) - 1; i > 0; i-- {
+		era := eras[i]
+
+		// Avoid connecting to the era endpoints if the start time is known
+		if !era.StartTime.IsZero() && era.StartTime.After(timestamp) {
+			continue
+		}
+
+		rep, err := getEraReporter(i)
+		if err != nil {
+			return nil, fmt.Errorf("error while creating the reporter of era %s: %w", era.ChainID, err)
+		}
+
+		startTime, err := rep.getStartTime()
+		if err != nil {
+			return nil, fmt.Errorf("error while getting the start time of era %s: %w", era.ChainID, err)
+		}
+
+		if !startTime.After(timestamp) {
+			log.Debug().Str("chain", cfg.Name).Str("chain id", era.ChainID).Time("start time", startTime).
+				Msg("using chain era")
+			return rep, nil
+		}
+	}
+
+	return getEraReporter(0)
+}
+
+// getStartTime returns the time at which the era of the reporter started
+func (r *Reporter) getStartTime() (time.Time, error) {
+	if !r.era.StartTime.IsZero() {
+		return r.era.StartTime, nil
+	}
+
+	r.startTimeMutex.Lock()
+	defer r.startTimeMutex.Unlock()
+
+	if !r.startTime.IsZero() {
+		return r.startTime, nil
+	}
+
+	startHeight, err := r.getStartHeight()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	header, err := r.client.Header(startHeight)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error while getting the start block: %w", err)
+	}
+
+	r.startTime = header.Time
+	return r.startTime, nil
+}
+
+// getStartHeight returns the height of the first block of the era of the reporter
+func (r *Reporter) getStartHeight() (int64, error) {
+	if r.era.StartHeight != 0 {
+		return r.era.StartHeight, nil
+	}
+
+	minHeight, err := r.client.MinHeight()
+	if err != nil {
+		return 0, fmt.Errorf("error while getting the genesis: %w", err)
+	}
+
+	return minHeight, nil
+}
+
+// getEndHeight returns the height of the last block of the era of the reporter
+func (r *Reporter) getEndHeight() (int64, error) {
+	if r.era.EndHeight != 0 {
+		return r.era.EndHeight, nil
+	}
+
+	latestHeight, err := r.client.LatestHeight()
+	if err != nil {
+		return 0, fmt.Errorf("error while getting latest height: %w", err)
+	}
+
+	return latestHeight, nil
+}
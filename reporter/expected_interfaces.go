@@ -1,11 +1,31 @@
 package reporter
 
 import (
+	"time"
+
 	tmtypes "github.com/cometbft/cometbft/types"
+
+	"github.com/riccardom/briatore/types"
 )
 
 type CosmosClient interface {
 	MinHeight() (int64, error)
 	LatestHeight() (int64, error)
-	Block(height int64) (*tmtypes.Block, error)
+	Header(height int64) (*tmtypes.Header, error)
+}
+
+// ModuleReporter represents a reporter that returns the amounts held inside a chain-specific module
+type ModuleReporter interface {
+	// Name returns the name of the module, which is used to enable it inside the chain config
+	Name() string
+
+	// GetAmount returns the amounts held by the given address inside the module at the provided height
+	GetAmount(address string, height int64) (types.Holdings, error)
+}
+
+// PriceSource represents the source of the prices used to compute the value of the amounts
+type PriceSource interface {
+	// GetPriceData returns the price of the given asset at the provided point in time, measured in the given currency.
+	// If the price is not known, found is false.
+	GetPriceData(asset *types.Asset, timestamp time.Time, currency string) (priceData types.PriceData, found bool, err error)
 }
@@ -0,0 +1,98 @@
+package reporter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/riccardom/briatore/reporter/stride"
+	"github.com/riccardom/briatore/types"
+)
+
+// getLiquidStakingPrice returns the price of the liquid staking token described by the given config,
+// computed as the price of the underlying token multiplied by the redemption rate at the given point in time.
+// The returned data keeps the details of the underlying price (e.g. whether it has been provided manually).
+// If the price of the underlying token is not known, found is false.
+func (r *Reporter) getLiquidStakingPrice(
+	lsCfg *types.LiquidStakingConfig, assets types.Assets, timestamp time.Time, cfg *types.Config,
+) (priceData types.PriceData, found bool, err error) {
+	hostZone, err := r.getHostZone(lsCfg, timestamp, cfg)
+	if err != nil {
+		return types.PriceData{}, false, err
+	}
+
+	redemptionRate, err := hostZone.GetRedemptionRate()
+	if err != nil {
+		return types.PriceData{}, false, err
+	}
+
+	underlying, found := assets.GetAssetByCoinDenom(hostZone.HostDenom)
+	if !found {
+		return types.PriceData{}, false, fmt.Errorf("underlying asset of %s not found: %s", lsCfg.Denom, hostZone.HostDenom)
+	}
+
+	underlyingPriceData, found, err := r.prices.GetPriceData(underlying, timestamp, cfg.Report.Currency)
+	if err != nil || !found {
+		return types.PriceData{}, found, err
+	}
+	underlyingPrice := underlyingPriceData.Price
+
+	log.Debug().Str("denom", lsCfg.Denom).Str("redemption rate", redemptionRate.String()).
+		Float64("underlying price", underlyingPrice).Msg("computed liquid staking price")
+
+	rate, err := redemptionRate.Float64()
+	if err != nil {
+		return types.PriceData{}, false, err
+	}
+
+	priceData = underlyingPriceData
+	priceData.Asset = lsCfg.Denom
+	priceData.Price = underlyingPrice * rate
+	return priceData, true, nil
+}
+
+// getHostZone returns the host zone described by the given config, read from the chain where the liquid staking
+// protocol lives at the block that is nearest to the given timestamp
+func (r *Reporter) getHostZone(lsCfg *types.LiquidStakingConfig, timestamp time.Time, cfg *types.Config) (*stride.HostZone, error) {
+	chainCfg, found := cfg.GetChainConfig(lsCfg.Chain)
+	if !found {
+		return nil, fmt.Errorf("chain %s not found inside the config", lsCfg.Chain)
+	}
+
+	// The reporter depends on the era of the chain that contains the timestamp
+	hostReporter, err := r.hostReporters.GetReporter(chainCfg, timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating the %s reporter: %w", chainCfg.Name, err)
+	}
+
+	blockData, err := hostReporter.GetBlockNearTimestamp(timestamp, cfg.Report)
+	if err != nil {
+		return nil, err
+	}
+
+	if blockData.IsZero() {
+		return nil, fmt.Errorf("chain %s did not exist at %s", chainCfg.Name, timestamp)
+	}
+
+	// Amounts are fetched concurrently, so we need to make sure the cache is not accessed at the same time
+	key := fmt.Sprintf("%s/%s/%d", chainCfg.Name, lsCfg.HostZone, blockData.Height)
+	r.hostMutex.Lock()
+	hostZone, ok := r.hostZones[key]
+	r.hostMutex.Unlock()
+	if ok {
+		return hostZone, nil
+	}
+
+	// The headers of each endpoint are added by the router itself
+	hostZone, err = stride.NewReporter(hostReporter.router, nil).GetHostZone(lsCfg.HostZone, blockData.Height)
+	if err != nil {
+		return nil, err
+	}
+
+	r.hostMutex.Lock()
+	r.hostZones[key] = hostZone
+	r.hostMutex.Unlock()
+
+	return hostZone, nil
+}
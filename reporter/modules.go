@@ -0,0 +1,65 @@
+package reporter
+
+import (
+	"fmt"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	"google.golang.org/grpc"
+
+	"github.com/riccardom/briatore/reporter/osmosis"
+	"github.com/riccardom/briatore/types"
+)
+
+// ModuleReporterCreator represents a function that allows to build a new ModuleReporter instance
+type ModuleReporterCreator func(grpcConnection grpc.ClientConnInterface, cdc codec.Codec) (ModuleReporter, error)
+
+var (
+	moduleReporters = map[string]ModuleReporterCreator{}
+)
+
+func init() {
+	RegisterModuleReporter(osmosis.ModuleName, func(grpcConnection grpc.ClientConnInterface, cdc codec.Codec) (ModuleReporter, error) {
+		// The headers of each endpoint are added by the connection itself
+		return osmosis.NewReporter(grpcConnection, nil, cdc)
+	})
+}
+
+// RegisterModuleReporter registers the given creator so that it can be used to build the module reporter
+// having the provided name. Chains can enable the module by adding its name inside the modules list of their config.
+func RegisterModuleReporter(name string, creator ModuleReporterCreator) {
+	if _, ok := moduleReporters[name]; ok {
+		panic(fmt.Errorf("module reporter %s already registered", name))
+	}
+	moduleReporters[name] = creator
+}
+
+// buildModuleReporters builds the module reporters having the given names
+func buildModuleReporters(names []string, grpcConnection grpc.ClientConnInterface, cdc codec.Codec) ([]ModuleReporter, error) {
+	reporters := make([]ModuleReporter, len(names))
+	for i, name := range names {
+		creator, ok := moduleReporters[name]
+		if !ok {
+			return nil, fmt.Errorf("module reporter not found: %s", name)
+		}
+
+		reporter, err := creator(grpcConnection, cdc)
+		if err != nil {
+			return nil, fmt.Errorf("error while creating %s module reporter: %w", name, err)
+		}
+		reporters[i] = reporter
+	}
+	return reporters, nil
+}
+
+// getModulesAmount returns the amount that the given address holds inside the enabled modules at the provided height
+func (r *Reporter) getModulesAmount(address string, height int64) (types.Holdings, error) {
+	holdings := types.NewHoldings()
+	for _, module := range r.modules {
+		amount, err := module.GetAmount(address, height)
+		if err != nil {
+			return nil, fmt.Errorf("error while getting %s amount: %w", module.Name(), err)
+		}
+		holdings.Merge(amount)
+	}
+	return holdings, nil
+}
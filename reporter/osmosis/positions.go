@@ -0,0 +1,59 @@
+package osmosis
+
+import (
+	"fmt"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	clqueryproto "github.com/osmosis-labs/osmosis/v25/x/concentrated-liquidity/client/queryproto"
+	"github.com/osmosis-labs/osmosis/v25/x/concentrated-liquidity/model"
+	"github.com/rs/zerolog/log"
+
+	"github.com/riccardom/briatore/utils"
+)
+
+// getPositionsAmount returns the amount of tokens that the given address holds inside concentrated-liquidity
+// positions, including the spread rewards and incentives that have not been claimed yet
+func (r *Reporter) getPositionsAmount(address string, height int64) (sdk.Coins, error) {
+	ctx := utils.GetRequestContext(height, r.grpcHeaders)
+
+	var positions []model.FullPositionBreakdown
+	var nextKey []byte
+	var stop = false
+	for !stop {
+		res, err := r.concentratedLiquidityQueryClient.UserPositions(ctx, &clqueryproto.UserPositionsRequest{
+			Address: address,
+			Pagination: &query.PageRequest{
+				Key: nextKey,
+			},
+		})
+		if err != nil {
+			if isUnknownQueryError(err) {
+				// The concentrated-liquidity module did not exist at this height
+				log.Debug().Str("chain", "osmosis").Int64("height", height).Msg("concentrated-liquidity module not found")
+				return nil, nil
+			}
+			return nil, fmt.Errorf("error while querying user positions: %w", err)
+		}
+
+		positions = append(positions, res.Positions...)
+		nextKey = res.Pagination.NextKey
+		stop = len(nextKey) == 0
+	}
+
+	amount := sdk.NewCoins()
+	for _, position := range positions {
+		amount = amount.Add(position.Asset0, position.Asset1)
+		amount = amount.Add(position.ClaimableSpreadRewards...)
+		amount = amount.Add(position.ClaimableIncentives...)
+	}
+
+	return amount, nil
+}
+
+// isUnknownQueryError tells whether the given error has been returned because the queried module
+// was not registered on chain at the requested height
+func isUnknownQueryError(err error) bool {
+	return strings.Contains(err.Error(), "unknown query path")
+}
@@ -2,11 +2,16 @@ package osmosis
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/cosmos/cosmos-sdk/codec"
+	clqueryproto "github.com/osmosis-labs/osmosis/v25/x/concentrated-liquidity/client/queryproto"
+	cltypes "github.com/osmosis-labs/osmosis/v25/x/concentrated-liquidity/types"
 	gammtypes "github.com/osmosis-labs/osmosis/v25/x/gamm/types"
 	lockuptypes "github.com/osmosis-labs/osmosis/v25/x/lockup/types"
 	poolmanagergrpc "github.com/osmosis-labs/osmosis/v25/x/poolmanager/client/queryproto"
+	superfluidtypes "github.com/osmosis-labs/osmosis/v25/x/superfluid/types"
+	twapqueryproto "github.com/osmosis-labs/osmosis/v25/x/twap/client/queryproto"
 
 	"github.com/riccardom/briatore/types"
 	"github.com/riccardom/briatore/utils"
@@ -16,62 +21,110 @@ import (
 	"google.golang.org/grpc"
 )
 
+const (
+	ModuleName = "osmosis"
+)
+
 type Reporter struct {
 	cdc codec.Codec
 
 	grpcHeaders map[string]string
 
-	gammQueryClient        gammtypes.QueryClient
-	poolmanagerQueryClient poolmanagergrpc.QueryClient
-	lockupQueryClient      lockuptypes.QueryClient
+	gammQueryClient                  gammtypes.QueryClient
+	poolmanagerQueryClient           poolmanagergrpc.QueryClient
+	lockupQueryClient                lockuptypes.QueryClient
+	concentratedLiquidityQueryClient clqueryproto.QueryClient
+	superfluidQueryClient            superfluidtypes.QueryClient
+	twapQueryClient                  twapqueryproto.QueryClient
 }
 
 func NewReporter(grpcConnection grpc.ClientConnInterface, grpcHeaders map[string]string, cdc codec.Codec) (*Reporter, error) {
 	return &Reporter{
-		cdc:                    cdc,
-		grpcHeaders:            grpcHeaders,
-		gammQueryClient:        gammtypes.NewQueryClient(grpcConnection),
-		poolmanagerQueryClient: poolmanagergrpc.NewQueryClient(grpcConnection),
-		lockupQueryClient:      lockuptypes.NewQueryClient(grpcConnection),
+		cdc:                              cdc,
+		grpcHeaders:                      grpcHeaders,
+		gammQueryClient:                  gammtypes.NewQueryClient(grpcConnection),
+		poolmanagerQueryClient:           poolmanagergrpc.NewQueryClient(grpcConnection),
+		lockupQueryClient:                lockuptypes.NewQueryClient(grpcConnection),
+		concentratedLiquidityQueryClient: clqueryproto.NewQueryClient(grpcConnection),
+		superfluidQueryClient:            superfluidtypes.NewQueryClient(grpcConnection),
+		twapQueryClient:                  twapqueryproto.NewQueryClient(grpcConnection),
 	}, nil
 }
 
-func (r *Reporter) GetAmount(address string, height int64) (sdk.Coins, error) {
+// Name returns the name of the module
+func (r *Reporter) Name() string {
+	return ModuleName
+}
+
+// GetAmount returns the amount that the given address holds inside the Osmosis lockups and
+// concentrated-liquidity positions at the provided height
+func (r *Reporter) GetAmount(address string, height int64) (types.Holdings, error) {
 	log.Debug().Str("chain", "osmosis").Int64("height", height).Msg("getting amount")
 
-	gammBalance := sdk.NewCoins()
+	lockups := types.NewHoldings()
 
+	// The unlockable amount represents the matured locks that have not been withdrawn yet
 	log.Debug().Str("chain", "osmosis").Int64("height", height).Msg("getting unlockable amount")
 	unlockableAmount, err := r.getUnlockableAmount(address, height)
 	if err != nil {
 		return nil, err
 	}
-	gammBalance = gammBalance.Add(unlockableAmount...)
+	lockups.Add(types.CategoryOsmosisUnlocking, unlockableAmount...)
 
 	log.Debug().Str("chain", "osmosis").Int64("height", height).Msg("getting unlocking amount")
 	unlockingAmount, err := r.getUnlockingAmount(address, height)
 	if err != nil {
 		return nil, err
 	}
-	gammBalance = gammBalance.Add(unlockingAmount...)
+	lockups.Add(types.CategoryOsmosisUnlocking, unlockingAmount...)
 
 	log.Debug().Str("chain", "osmosis").Int64("height", height).Msg("getting locked amount")
 	lockedAmount, err := r.getLockedAmount(address, height)
 	if err != nil {
 		return nil, err
 	}
-	gammBalance = gammBalance.Add(lockedAmount...)
 
-	var balance sdk.Coins
-	for _, gammToken := range gammBalance {
-		amount, err := r.convertPoolShares(gammToken, height)
-		if err != nil {
-			return nil, err
+	// The locked amount includes the unlocking one as well (e.g. superfluid undelegating positions),
+	// so we need to remove it in order not to count it twice
+	lockedAmount, clamped := types.SubClamped(lockedAmount, unlockingAmount)
+	if clamped {
+		log.Warn().Str("chain", "osmosis").Str("address", address).Int64("height", height).
+			Msg("unlocking amount is greater than locked amount, clamping locked amount to zero")
+	}
+	lockups.Add(types.CategoryOsmosisLocked, lockedAmount...)
+
+	log.Debug().Str("chain", "osmosis").Int64("height", height).Msg("getting superfluid shares")
+	superfluidShares, err := r.getSuperfluidShares(address, height)
+	if err != nil {
+		return nil, err
+	}
+	lockupCoins := unlockableAmount.Add(unlockingAmount...).Add(lockedAmount...)
+	lockups.Add(types.CategoryOsmosisLocked, reconcileSuperfluidShares(lockupCoins, superfluidShares)...)
+
+	holdings := types.NewHoldings()
+	for _, category := range lockups.GetCategories() {
+		for _, gammToken := range lockups[category] {
+			// Locked concentrated-liquidity shares are already accounted for by the user positions
+			if strings.HasPrefix(gammToken.Denom, cltypes.ConcentratedLiquidityTokenPrefix) {
+				continue
+			}
+
+			amount, err := r.convertPoolShares(gammToken, height)
+			if err != nil {
+				return nil, err
+			}
+			holdings.Add(category, amount...)
 		}
-		balance = balance.Add(amount...)
 	}
 
-	return balance, nil
+	log.Debug().Str("chain", "osmosis").Int64("height", height).Msg("getting concentrated-liquidity positions amount")
+	positionsAmount, err := r.getPositionsAmount(address, height)
+	if err != nil {
+		return nil, err
+	}
+	holdings.Add(types.CategoryOsmosisLP, positionsAmount...)
+
+	return holdings, nil
 }
 
 // convertPoolShares converts the given GAMM token into the proper denoms
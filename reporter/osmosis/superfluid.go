@@ -0,0 +1,73 @@
+package osmosis
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	superfluidtypes "github.com/osmosis-labs/osmosis/v25/x/superfluid/types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/riccardom/briatore/utils"
+)
+
+// getSuperfluidShares returns the pool shares that the given address has superfluid delegated or is
+// superfluid undelegating at the given height
+func (r *Reporter) getSuperfluidShares(address string, height int64) (sdk.Coins, error) {
+	ctx := utils.GetRequestContext(height, r.grpcHeaders)
+
+	delegationsRes, err := r.superfluidQueryClient.SuperfluidDelegationsByDelegator(ctx, &superfluidtypes.SuperfluidDelegationsByDelegatorRequest{
+		DelegatorAddress: address,
+	})
+	if err != nil {
+		if isUnknownQueryError(err) {
+			// The superfluid module did not exist at this height
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error while querying superfluid delegations: %w", err)
+	}
+
+	undelegationsRes, err := r.superfluidQueryClient.SuperfluidUndelegationsByDelegator(ctx, &superfluidtypes.SuperfluidUndelegationsByDelegatorRequest{
+		DelegatorAddress: address,
+	})
+	if err != nil && !isUnknownQueryError(err) {
+		return nil, fmt.Errorf("error while querying superfluid undelegations: %w", err)
+	}
+
+	var records []superfluidtypes.SuperfluidDelegationRecord
+	records = append(records, delegationsRes.SuperfluidDelegationRecords...)
+	if err == nil {
+		records = append(records, undelegationsRes.SuperfluidDelegationRecords...)
+	} else {
+		// The undelegations query was not available at this height
+		log.Debug().Str("chain", "osmosis").Int64("height", height).Msg("superfluid undelegations query not found")
+	}
+
+	shares := sdk.NewCoins()
+	for _, record := range records {
+		shares = shares.Add(record.DelegationAmount)
+	}
+
+	return shares, nil
+}
+
+// reconcileSuperfluidShares makes sure that the given superfluid shares are counted exactly once.
+// Superfluid positions are backed by lockups, so their shares should already be part of the given lockup coins.
+// The synthetic delegations are not considered since they do not represent owned tokens, but only the staking
+// power of the underlying shares. The returned coins contain the shares that were missing from the lockup coins.
+func reconcileSuperfluidShares(lockupCoins sdk.Coins, superfluidShares sdk.Coins) sdk.Coins {
+	missing := sdk.NewCoins()
+	for _, share := range superfluidShares {
+		lockedAmount := lockupCoins.AmountOf(share.Denom)
+		if lockedAmount.GTE(share.Amount) {
+			continue
+		}
+
+		log.Warn().Str("chain", "osmosis").Str("denom", share.Denom).
+			Str("locked", lockedAmount.String()).Str("superfluid", share.Amount.String()).
+			Msg("superfluid shares not found inside lockups")
+
+		missing = missing.Add(sdk.NewCoin(share.Denom, share.Amount.Sub(lockedAmount)))
+	}
+
+	return missing
+}
@@ -0,0 +1,71 @@
+package osmosis
+
+import (
+	"fmt"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	poolmanagergrpc "github.com/osmosis-labs/osmosis/v25/x/poolmanager/client/queryproto"
+	poolmanagertypes "github.com/osmosis-labs/osmosis/v25/x/poolmanager/types"
+	twapqueryproto "github.com/osmosis-labs/osmosis/v25/x/twap/client/queryproto"
+	"github.com/rs/zerolog/log"
+
+	"github.com/riccardom/briatore/utils"
+)
+
+// GetTwapPrice returns the arithmetic TWAP of the base denom in terms of the quote denom inside the given pool,
+// computed from the start time up to the block at the provided height
+func (r *Reporter) GetTwapPrice(poolID uint64, baseDenom, quoteDenom string, startTime time.Time, height int64) (sdk.Dec, error) {
+	ctx := utils.GetRequestContext(height, r.grpcHeaders)
+
+	res, err := r.twapQueryClient.ArithmeticTwapToNow(ctx, &twapqueryproto.ArithmeticTwapToNowRequest{
+		PoolId:     poolID,
+		BaseAsset:  baseDenom,
+		QuoteAsset: quoteDenom,
+		StartTime:  startTime,
+	})
+	if err != nil {
+		return sdk.Dec{}, fmt.Errorf("error while querying twap of pool %d: %w", poolID, err)
+	}
+
+	return res.ArithmeticTwap, nil
+}
+
+// FindPool returns the id of the pool containing both the given denoms that has the highest liquidity
+// of the quote denom at the provided height. If no pool contains both denoms, found is false.
+func (r *Reporter) FindPool(baseDenom, quoteDenom string, height int64) (poolID uint64, found bool, err error) {
+	ctx := utils.GetRequestContext(height, r.grpcHeaders)
+
+	res, err := r.poolmanagerQueryClient.ListPoolsByDenom(ctx, &poolmanagergrpc.ListPoolsByDenomRequest{Denom: baseDenom})
+	if err != nil {
+		return 0, false, fmt.Errorf("error while querying pools of %s: %w", baseDenom, err)
+	}
+
+	bestLiquidity := sdk.ZeroInt()
+	for _, poolAny := range res.Pools {
+		var pool poolmanagertypes.PoolI
+		err = r.cdc.UnpackAny(poolAny, &pool)
+		if err != nil {
+			return 0, false, fmt.Errorf("error while unpacking pool: %w", err)
+		}
+
+		// The liquidity is read from the chain since not all the pool types can return their denoms directly
+		liquidityRes, err := r.poolmanagerQueryClient.TotalPoolLiquidity(ctx, &poolmanagergrpc.TotalPoolLiquidityRequest{
+			PoolId: pool.GetId(),
+		})
+		if err != nil {
+			return 0, false, fmt.Errorf("error while querying the pool liquidity: %w", err)
+		}
+
+		liquidity := liquidityRes.Liquidity.AmountOf(quoteDenom)
+		if liquidity.GT(bestLiquidity) {
+			poolID, found, bestLiquidity = pool.GetId(), true, liquidity
+		}
+	}
+
+	if found {
+		log.Debug().Str("base", baseDenom).Str("quote", quoteDenom).Uint64("pool", poolID).Msg("found twap pool")
+	}
+
+	return poolID, found, nil
+}
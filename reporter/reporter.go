@@ -2,168 +2,327 @@ package reporter
 
 import (
 	"fmt"
-	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"google.golang.org/grpc"
 
+	wasmtypes "github.com/CosmWasm/wasmd/x/wasm/types"
 	"github.com/cosmos/cosmos-sdk/codec"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	distrtypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	ibctransfertypes "github.com/cosmos/ibc-go/v7/modules/apps/transfer/types"
 	"github.com/rs/zerolog/log"
 
-	"github.com/riccardom/briatore/cosmos"
-	"github.com/riccardom/briatore/gprc"
+	"github.com/riccardom/briatore/reporter/stride"
 	"github.com/riccardom/briatore/types"
 	"github.com/riccardom/briatore/utils"
 )
 
+const (
+	// decPrecision is the number of decimals supported by sdk.Dec
+	decPrecision = 18
+)
+
 type Reporter struct {
 	cdc codec.Codec
 
 	chain *types.ChainConfig
+	era   *types.EraConfig
 
-	grpcConnection grpc.ClientConnInterface
-	grpcHeaders    map[string]string
+	// startTime caches the time at which the era started, when it is not configured
+	startTimeMutex sync.Mutex
+	startTime      time.Time
 
-	client        CosmosClient
-	bankClient    banktypes.QueryClient
-	stakingClient stakingtypes.QueryClient
-}
+	// router sends each query to the endpoints that have the data at the queried height
+	router *EndpointsRouter
 
-func NewReporter(cfg *types.ChainConfig, cdc codec.Codec) (*Reporter, error) {
-	// Try pinging the addresses
-	httpClient := &http.Client{Timeout: 10 * time.Second}
-	if err := utils.PingAddress(cfg.RPCAddress, httpClient); err != nil {
-		return nil, fmt.Errorf("error while pinging the RPC address: %w", err)
-	}
+	client             CosmosClient
+	bankClient         banktypes.QueryClient
+	stakingClient      stakingtypes.QueryClient
+	distributionClient distrtypes.QueryClient
+	transferClient     ibctransfertypes.QueryClient
+	wasmClient         wasmtypes.QueryClient
+
+	modules []ModuleReporter
+	prices  PriceSource
 
-	rpcAddress, headers := utils.ParseAddressHeaders(cfg.RPCAddress)
-	grpcConnection, err := gprc.NewConnection(rpcAddress, cdc)
+	// hostReporters contains the reporters of the chains where liquid staking protocols live
+	hostReporters *Reporters
+
+	// hostZones caches the host zones used to value liquid staking tokens, guarded by hostMutex
+	hostMutex sync.Mutex
+	hostZones map[string]*stride.HostZone
+}
+
+// newEraReporter returns a new Reporter that reads the data of the given era of the provided chain.
+// The given reporters are used to read the data of the chains where liquid staking protocols live.
+func newEraReporter(
+	cfg *types.ChainConfig, era *types.EraConfig, cdc codec.Codec, prices PriceSource, hostReporters *Reporters,
+) (*Reporter, error) {
+	router, err := NewEndpointsRouter(cfg.Name, era.RPCAddresses, cdc)
 	if err != nil {
 		return nil, err
 	}
 
-	cosmosClient, err := cosmos.NewClient(cfg.RPCAddress)
+	modules, err := buildModuleReporters(cfg.GetModules(), router, cdc)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Reporter{
-		cdc:            cdc,
-		chain:          cfg,
-		grpcConnection: grpcConnection,
-		grpcHeaders:    headers,
-		client:         cosmosClient,
-		bankClient:     banktypes.NewQueryClient(grpcConnection),
-		stakingClient:  stakingtypes.NewQueryClient(grpcConnection),
+		cdc:                cdc,
+		chain:              cfg,
+		era:                era,
+		router:             router,
+		client:             router,
+		bankClient:         banktypes.NewQueryClient(router),
+		stakingClient:      stakingtypes.NewQueryClient(router),
+		distributionClient: distrtypes.NewQueryClient(router),
+		transferClient:     ibctransfertypes.NewQueryClient(router),
+		wasmClient:         wasmtypes.NewQueryClient(router),
+		modules:            modules,
+		prices:             prices,
+		hostReporters:      hostReporters,
+		hostZones:          map[string]*stride.HostZone{},
 	}, nil
 }
 
-// GetAmounts returns the amount that the given addresses hold at the point in time that is closest to the given timestamp.
-// If the provided timestamp is before the genesis, an empty report will be returned instead.
-// NOTE. Calling this method will close the node as soon as it returns
-func (r *Reporter) GetAmounts(addresses []string, timestamp time.Time, cfg *types.ReportConfig) ([]*types.Amount, error) {
-	blockData, err := r.getBlockNearTimestamp(timestamp)
+// GetConnection returns the connection used to query the chain, which routes each query to the endpoints of the era
+func (r *Reporter) GetConnection() grpc.ClientConnInterface {
+	return r.router
+}
+
+// GetChainID returns the chain id of the era the reporter reads the data of, if configured
+func (r *Reporter) GetChainID() string {
+	return r.era.ChainID
+}
+
+// Stop stops the clients used by the reporter
+func (r *Reporter) Stop() {
+	r.router.Stop()
+}
+
+// GetServedEndpoints returns the addresses of the endpoints that have served the data read so far
+func (r *Reporter) GetServedEndpoints() []string {
+	return r.router.GetServedEndpoints()
+}
+
+// GetAmounts returns the amount that the given addresses hold at the block near the given timestamp,
+// along with the data of such block which is chosen based on the configured policy.
+// If the chain didn't exist at the provided timestamp, an empty block and report will be returned instead.
+func (r *Reporter) GetAmounts(
+	addresses []string, timestamp time.Time, cfg *types.Config, workers *utils.WorkerPool,
+) (types.BlockData, []*types.Amount, error) {
+	var blockData types.BlockData
+	var err error
+	workers.Run(func() {
+		blockData, err = r.GetBlockNearTimestamp(timestamp, cfg.Report)
+	})
+	if err != nil {
+		return types.BlockData{}, nil, err
+	}
+
+	if blockData.IsZero() {
+		// If the height is 0 it means the chain didn't exist, so we just return an empty amount
+		return types.BlockData{}, nil, nil
+	}
+
+	bondDenom, err := r.getBondDenom(blockData.Height)
+	if err != nil {
+		return types.BlockData{}, nil, fmt.Errorf("error while getting bond denom: %w", err)
+	}
+
+	// Fetch the addresses amounts concurrently, storing them by index so that the ordering is deterministic
+	chainWorkers := utils.NewWorkerPool(cfg.Report.GetConcurrency().MaxWorkersPerChain)
+	addressesAmounts := make([][]*types.Amount, len(addresses))
+	addressesErrs := make([]error, len(addresses))
+
+	var wg sync.WaitGroup
+	for i, address := range addresses {
+		wg.Add(1)
+		go func(i int, address string) {
+			defer wg.Done()
+			chainWorkers.Run(func() {
+				workers.Run(func() {
+					addressesAmounts[i], addressesErrs[i] = r.getAddressAmounts(address, blockData, bondDenom, cfg)
+				})
+			})
+		}(i, address)
+	}
+	wg.Wait()
+
+	var amounts []*types.Amount
+	for i := range addresses {
+		if addressesErrs[i] != nil {
+			return types.BlockData{}, nil, addressesErrs[i]
+		}
+		amounts = append(amounts, addressesAmounts[i]...)
+	}
+
+	return blockData, amounts, nil
+}
+
+// getAddressAmounts returns the amounts that the given address holds at the provided block
+func (r *Reporter) getAddressAmounts(address string, blockData types.BlockData, bondDenom string, cfg *types.Config) ([]*types.Amount, error) {
+	holdings, err := r.getHeightAmount(address, bondDenom, blockData.Height)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get the overall hold amount
-	sum := sdk.NewCoins()
-	for _, address := range addresses {
-		amount, err := r.getHeightAmount(address, blockData.Height)
+	// Get the amounts of each category
+	var amounts []*types.Amount
+	for _, category := range holdings.GetCategories() {
+		categoryAmounts, err := r.getCoinsAmounts(blockData, address, holdings[category], category, cfg)
 		if err != nil {
 			return nil, err
 		}
-		sum = sum.Add(amount...)
+		amounts = append(amounts, categoryAmounts...)
 	}
 
-	// Get the amounts
-	return r.getCoinsAmounts(blockData.Timestamp, sum, cfg)
+	return amounts, nil
 }
 
-// getHeightAmount returns the hold amount at the given height
-func (r *Reporter) getHeightAmount(address string, height int64) (sdk.Coins, error) {
-	if height == 0 {
-		// If the height is 0 it means the chain didn't exist, so we just return an empty amount
-		return nil, nil
-	}
-
+// getHeightAmount returns the hold amount at the given height, split by category
+func (r *Reporter) getHeightAmount(address string, bondDenom string, height int64) (types.Holdings, error) {
 	log.Debug().Str("chain", r.chain.Name).Str("address", address).Int64("height", height).Msg("getting height report")
 
-	bondDenom, err := types.GetBaseNativeDenom(r.chain.Name)
-	if err != nil {
-		return nil, fmt.Errorf("error while getting base native denom: %w", err)
-	}
+	holdings := types.NewHoldings()
 
 	balance, err := r.getBalanceAmount(address, height)
 	if err != nil {
 		return nil, fmt.Errorf("error while getting balance: %w", err)
 	}
+	holdings.Add(types.CategoryBank, balance...)
 
 	delegations, err := r.getDelegationsAmount(address, height)
 	if err != nil {
 		return nil, fmt.Errorf("error while getting delegations: %w", err)
 	}
-	balance = balance.Add(delegations...)
 
 	redelegations, err := r.getReDelegationsAmount(address, bondDenom, height)
 	if err != nil {
 		return nil, fmt.Errorf("error while gettig redelegations: %w", err)
 	}
-	balance = balance.Add(redelegations...)
+	holdings.Add(types.CategoryRedelegating, redelegations...)
+
+	// Redelegated tokens are already delegated to the destination validator,
+	// so we remove them from the delegations in order not to count them twice
+	delegated, clamped := types.SubClamped(delegations, redelegations)
+	if clamped {
+		log.Warn().Str("chain", r.chain.Name).Str("address", address).Int64("height", height).
+			Msg("redelegating amount is greater than delegated amount, clamping delegated amount to zero")
+	}
+	holdings.Add(types.CategoryDelegated, delegated...)
 
 	unbondingDelegations, err := r.getUnbondingDelegationsAmount(address, bondDenom, height)
 	if err != nil {
 		return nil, fmt.Errorf("error while getting unbonding delegations: %w", err)
 	}
-	balance = balance.Add(unbondingDelegations...)
+	holdings.Add(types.CategoryUnbonding, unbondingDelegations...)
 
-	osmosisAmount, err := r.getOsmosisAmount(address, height)
+	rewards, err := r.getRewardsAmount(address, height)
 	if err != nil {
-		return nil, fmt.Errorf("error while getting osmosis amount: %w", err)
+		return nil, fmt.Errorf("error while getting rewards: %w", err)
 	}
-	balance = balance.Add(osmosisAmount...)
+	holdings.Add(types.CategoryRewards, rewards...)
 
-	return balance, nil
+	cw20Amount, err := r.getCW20Amount(address, height)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting cw20 amount: %w", err)
+	}
+	holdings.Add(types.CategoryCW20, cw20Amount...)
+
+	modulesAmount, err := r.getModulesAmount(address, height)
+	if err != nil {
+		return nil, err
+	}
+	holdings.Merge(modulesAmount)
+
+	return holdings, nil
 }
 
-// getCoinsAmounts returns the corresponding fiat value for the given coins at the provided point in time
-func (r *Reporter) getCoinsAmounts(timestamp time.Time, coins sdk.Coins, cfg *types.ReportConfig) ([]*types.Amount, error) {
-	log.Debug().Str("chain", r.chain.Name).Time("timestamp", timestamp).Msg("computing report fiat value")
+// getCoinsAmounts returns the corresponding fiat value for the given coins held by the provided address
+// at the given block
+func (r *Reporter) getCoinsAmounts(blockData types.BlockData, address string, coins sdk.Coins, category types.Category, cfg *types.Config) ([]*types.Amount, error) {
+	log.Debug().Str("chain", r.chain.Name).Str("address", address).Time("timestamp", blockData.Timestamp).Msg("computing report fiat value")
+
+	origin := types.NewOrigin(r.chain.Name, address, blockData.Height, blockData.Timestamp)
 
 	assets, err := types.GetAssets()
 	if err != nil {
 		return nil, err
 	}
+	assets = append(assets, r.chain.GetCW20Assets()...)
 
 	var amounts []*types.Amount
 	for _, coin := range coins {
 		// Get the CoinGecko ID, if not found just return a value of 0
-		asset, found := assets.GetAssetByCoinDenom(coin.Denom)
+		asset, found := r.getAssetByCoinDenom(assets, coin.Denom, blockData.Height)
 		if !found {
 			log.Info().Str("denom", coin.Denom).Msg("asset not found")
 			continue
 		}
 
-		// Get the token price
-		tokenPrice, err := GetCoinPrice(asset.CoingeckoID, timestamp, cfg.Currency)
+		tokenAmount := coin.Amount.ToLegacyDec().QuoInt(types.GetPower(asset.GetMaxExponent()))
+
+		// Get the token price, valuing the amount at zero if it is not known
+		priceData, found, err := r.getAssetPrice(asset, assets, blockData.Timestamp, cfg)
 		if err != nil {
 			return nil, err
 		}
-		tokenPriceDec, err := sdk.NewDecFromStr(fmt.Sprintf("%.2f", tokenPrice))
+
+		if !found {
+			log.Warn().Str("chain", r.chain.Name).Str("asset", asset.Symbol).Time("timestamp", blockData.Timestamp).
+				Msg("price not found, valuing amount at zero")
+			amounts = append(amounts, types.NewAmount(asset, origin, category, tokenAmount, sdk.ZeroDec()).WithMissingPrice())
+			continue
+		}
+
+		tokenPriceDec, err := priceToDec(priceData.Price)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("error while parsing price of %s: %w", asset.Symbol, err)
 		}
 
 		// Compute the token value
-		tokenAmount := coin.Amount.ToLegacyDec().QuoInt(types.GetPower(asset.GetMaxExponent()))
 		tokenValue := tokenAmount.Mul(tokenPriceDec)
 
-		amounts = append(amounts, types.NewAmount(asset, tokenAmount, tokenValue))
+		amount := types.NewAmount(asset, origin, category, tokenAmount, tokenValue)
+		if priceData.Manual {
+			amount = amount.WithManualPrice(priceData.Source)
+		}
+		if priceData.ExchangeRate != nil {
+			amount = amount.WithExchangeRate(priceData.ExchangeRate)
+		}
+		amounts = append(amounts, amount)
 	}
 
 	return amounts, nil
 }
+
+// priceToDec converts the given price into a decimal, keeping its full precision since illiquid tokens
+// can be worth less than a cent. Decimals beyond the ones supported by sdk.Dec are truncated.
+func priceToDec(price float64) (sdk.Dec, error) {
+	value := strconv.FormatFloat(price, 'f', -1, 64)
+	if i := strings.Index(value, "."); i != -1 && len(value)-i-1 > decPrecision {
+		value = value[:i+1+decPrecision]
+	}
+	return sdk.NewDecFromStr(value)
+}
+
+// getAssetPrice returns the price of the given asset at the provided point in time.
+// Liquid staking tokens that have been configured to do so are valued using the redemption rate of their protocol.
+// If the price is not known, found is false.
+func (r *Reporter) getAssetPrice(
+	asset *types.Asset, assets types.Assets, timestamp time.Time, cfg *types.Config,
+) (priceData types.PriceData, found bool, err error) {
+	if lsCfg, found := cfg.Report.GetLiquidStakingConfig(asset); found {
+		return r.getLiquidStakingPrice(lsCfg, assets, timestamp, cfg)
+	}
+
+	return r.prices.GetPriceData(asset, timestamp, cfg.Report.Currency)
+}
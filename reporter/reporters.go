@@ -0,0 +1,87 @@
+package reporter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+
+	"github.com/riccardom/briatore/types"
+)
+
+// Reporters caches the reporters of the eras of the chains, so that each of them is created only once
+// and can be reused to read the data at different points in time
+type Reporters struct {
+	cdc    codec.Codec
+	prices PriceSource
+
+	mutex  sync.Mutex
+	chains map[string]*chainReporters
+}
+
+// chainReporters contains the reporters of the eras of a single chain, indexed by era
+type chainReporters struct {
+	mutex sync.Mutex
+	eras  map[int]*Reporter
+}
+
+func NewReporters(cdc codec.Codec, prices PriceSource) *Reporters {
+	return &Reporters{
+		cdc:    cdc,
+		prices: prices,
+		chains: map[string]*chainReporters{},
+	}
+}
+
+// GetReporter returns the reporter that reads the data of the given chain from the era containing the timestamp,
+// creating it only if it has not been used before. The reporters of the chains where liquid staking protocols live
+// are read from these same reporters, so that they are shared as well.
+func (r *Reporters) GetReporter(cfg *types.ChainConfig, timestamp time.Time) (*Reporter, error) {
+	chain := r.getChainReporters(cfg.Name)
+
+	// Lock the chain only, so that the reporters of different chains can be created concurrently
+	chain.mutex.Lock()
+	defer chain.mutex.Unlock()
+
+	eras := cfg.GetEras()
+	return selectEraReporter(cfg, timestamp, func(index int) (*Reporter, error) {
+		if rep, ok := chain.eras[index]; ok {
+			return rep, nil
+		}
+
+		rep, err := newEraReporter(cfg, eras[index], r.cdc, r.prices, r)
+		if err != nil {
+			return nil, err
+		}
+		chain.eras[index] = rep
+		return rep, nil
+	})
+}
+
+// getChainReporters returns the reporters of the chain having the given name
+func (r *Reporters) getChainReporters(chainName string) *chainReporters {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	chain, ok := r.chains[chainName]
+	if !ok {
+		chain = &chainReporters{eras: map[int]*Reporter{}}
+		r.chains[chainName] = chain
+	}
+	return chain
+}
+
+// Stop stops all the reporters that have been created
+func (r *Reporters) Stop() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, chain := range r.chains {
+		chain.mutex.Lock()
+		for _, rep := range chain.eras {
+			rep.Stop()
+		}
+		chain.eras = map[int]*Reporter{}
+		chain.mutex.Unlock()
+	}
+}
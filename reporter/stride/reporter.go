@@ -0,0 +1,42 @@
+package stride
+
+import (
+	"fmt"
+
+	"google.golang.org/grpc"
+
+	"github.com/riccardom/briatore/utils"
+)
+
+const (
+	hostZoneQueryPath = "/stride.stakeibc.Query/HostZone"
+)
+
+type Reporter struct {
+	grpcConnection grpc.ClientConnInterface
+	grpcHeaders    map[string]string
+}
+
+func NewReporter(grpcConnection grpc.ClientConnInterface, grpcHeaders map[string]string) *Reporter {
+	return &Reporter{
+		grpcConnection: grpcConnection,
+		grpcHeaders:    grpcHeaders,
+	}
+}
+
+// GetHostZone returns the host zone having the given chain id at the provided height
+func (r *Reporter) GetHostZone(chainID string, height int64) (*HostZone, error) {
+	ctx := utils.GetRequestContext(height, r.grpcHeaders)
+
+	var res QueryGetHostZoneResponse
+	err := r.grpcConnection.Invoke(ctx, hostZoneQueryPath, &QueryGetHostZoneRequest{ChainId: chainID}, &res)
+	if err != nil {
+		return nil, fmt.Errorf("error while querying host zone %s: %w", chainID, err)
+	}
+
+	if res.HostZone == nil {
+		return nil, fmt.Errorf("host zone %s not found", chainID)
+	}
+
+	return res.HostZone, nil
+}
@@ -0,0 +1,49 @@
+package stride
+
+import (
+	"fmt"
+
+	sdkmath "cosmossdk.io/math"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/gogoproto/proto"
+)
+
+// The following types are a minimal copy of the ones defined inside the Stride stakeibc module,
+// containing only the fields that are needed to compute the redemption rate of a host zone.
+// REF: https://github.com/Stride-Labs/stride/blob/main/proto/stride/stakeibc/query.proto
+
+type QueryGetHostZoneRequest struct {
+	ChainId string `protobuf:"bytes,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+}
+
+func (m *QueryGetHostZoneRequest) Reset()         { *m = QueryGetHostZoneRequest{} }
+func (m *QueryGetHostZoneRequest) String() string { return proto.CompactTextString(m) }
+func (*QueryGetHostZoneRequest) ProtoMessage()    {}
+
+type QueryGetHostZoneResponse struct {
+	HostZone *HostZone `protobuf:"bytes,1,opt,name=host_zone,json=hostZone,proto3" json:"host_zone,omitempty"`
+}
+
+func (m *QueryGetHostZoneResponse) Reset()         { *m = QueryGetHostZoneResponse{} }
+func (m *QueryGetHostZoneResponse) String() string { return proto.CompactTextString(m) }
+func (*QueryGetHostZoneResponse) ProtoMessage()    {}
+
+type HostZone struct {
+	ChainId        string `protobuf:"bytes,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	HostDenom      string `protobuf:"bytes,9,opt,name=host_denom,json=hostDenom,proto3" json:"host_denom,omitempty"`
+	RedemptionRate string `protobuf:"bytes,11,opt,name=redemption_rate,json=redemptionRate,proto3" json:"redemption_rate,omitempty"`
+}
+
+func (m *HostZone) Reset()         { *m = HostZone{} }
+func (m *HostZone) String() string { return proto.CompactTextString(m) }
+func (*HostZone) ProtoMessage()    {}
+
+// GetRedemptionRate returns the redemption rate of the host zone as a decimal value.
+// Decimal values are serialized as the integer representation of the value with 18 decimal places.
+func (m *HostZone) GetRedemptionRate() (sdk.Dec, error) {
+	value, ok := sdkmath.NewIntFromString(m.RedemptionRate)
+	if !ok {
+		return sdk.Dec{}, fmt.Errorf("invalid redemption rate: %s", m.RedemptionRate)
+	}
+	return sdk.NewDecFromBigIntWithPrec(value.BigInt(), sdk.Precision), nil
+}
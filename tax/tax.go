@@ -0,0 +1,165 @@
+package tax
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/riccardom/briatore/types"
+)
+
+// ComputeTax returns the given tax due on the provided amounts, which must be the ones held at the given date
+func ComputeTax(tax types.Tax, cfg *types.ReportConfig, amounts []*types.Amount, date time.Time) (*types.TaxReport, error) {
+	switch tax {
+	case types.TaxIVCA:
+		return ComputeIVCA(cfg, amounts, date)
+	default:
+		return nil, fmt.Errorf("invalid tax value: %d", tax)
+	}
+}
+
+// ComputeIVCA returns the Italian wealth tax on crypto-assets due on the provided amounts, which must be the ones
+// held at the given date. The tax period is the calendar year of the date, and the value of each amount is pro-rated
+// by the days it has been held during such period and by its ownership share before applying the rate.
+func ComputeIVCA(cfg *types.ReportConfig, amounts []*types.Amount, date time.Time) (*types.TaxReport, error) {
+	ivcaCfg := cfg.GetTaxConfig().GetIVCAConfig()
+
+	rate, err := ivcaCfg.GetRate()
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := cfg.GetLocation()
+	if err != nil {
+		return nil, err
+	}
+
+	period := NewPeriod(date, location)
+
+	var symbols []string
+	assetsTaxes := map[string]*types.AssetTax{}
+	for _, amount := range amounts {
+		holding, err := period.GetHolding(ivcaCfg, amount)
+		if err != nil {
+			return nil, err
+		}
+
+		assetTax, ok := assetsTaxes[amount.Asset.Symbol]
+		if !ok {
+			symbols = append(symbols, amount.Asset.Symbol)
+			assetTax = types.NewAssetTax(amount.Asset.Symbol, sdk.ZeroDec(), sdk.ZeroDec(), sdk.ZeroDec())
+			assetsTaxes[amount.Asset.Symbol] = assetTax
+		}
+
+		assetTax.Value = assetTax.Value.Add(amount.Value)
+		assetTax.TaxableValue = assetTax.TaxableValue.Add(holding.GetTaxableValue(amount.Value, period))
+	}
+
+	sort.Strings(symbols)
+
+	assets := make([]*types.AssetTax, len(symbols))
+	for i, symbol := range symbols {
+		assets[i] = assetsTaxes[symbol]
+		assets[i].Tax = assets[i].TaxableValue.Mul(rate)
+	}
+
+	return types.NewTaxReport(types.TaxIVCA, rate, period.GetDays(), assets), nil
+}
+
+// --------------------------------------------------------------------------------------------------------------------
+
+// Period represents a tax period, which goes from the first to the last day of a calendar year
+type Period struct {
+	Start    time.Time
+	End      time.Time
+	location *time.Location
+}
+
+// NewPeriod returns the tax period containing the given date inside the provided location
+func NewPeriod(date time.Time, location *time.Location) Period {
+	start := time.Date(types.GetDay(date, location).Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	return Period{
+		Start:    start,
+		End:      start.AddDate(1, 0, -1),
+		location: location,
+	}
+}
+
+// GetDays returns the number of days of the period
+func (p Period) GetDays() int64 {
+	return types.DaysBetween(p.Start, p.End)
+}
+
+// GetHolding returns the ownership share and the days held during the period of the given amount,
+// based on the provided config. Amounts are considered held for the whole period and fully owned
+// unless configured otherwise.
+func (p Period) GetHolding(cfg *types.IVCAConfig, amount *types.Amount) (Holding, error) {
+	holdingCfg, found := cfg.GetHoldingConfig(amount.Origin.Address, amount.Asset)
+	if !found {
+		return NewHolding(sdk.OneDec(), p.GetDays()), nil
+	}
+
+	share, err := holdingCfg.GetShare()
+	if err != nil {
+		return Holding{}, err
+	}
+
+	heldFrom, heldUntil := p.Start, p.End
+	if holdingCfg.HeldFrom != "" {
+		date, err := types.ParseDate(holdingCfg.HeldFrom, p.location)
+		if err != nil {
+			return Holding{}, fmt.Errorf("invalid holding start date: %w", err)
+		}
+		heldFrom = maxTime(heldFrom, types.GetDay(date, p.location))
+	}
+
+	if holdingCfg.HeldUntil != "" {
+		date, err := types.ParseDate(holdingCfg.HeldUntil, p.location)
+		if err != nil {
+			return Holding{}, fmt.Errorf("invalid holding end date: %w", err)
+		}
+		heldUntil = minTime(heldUntil, types.GetDay(date, p.location))
+	}
+
+	if heldUntil.Before(heldFrom) {
+		return NewHolding(share, 0), nil
+	}
+
+	return NewHolding(share, types.DaysBetween(heldFrom, heldUntil)), nil
+}
+
+// Holding contains the ownership share of an amount and the days it has been held during a period
+type Holding struct {
+	Share    sdk.Dec
+	DaysHeld int64
+}
+
+func NewHolding(share sdk.Dec, daysHeld int64) Holding {
+	return Holding{
+		Share:    share,
+		DaysHeld: daysHeld,
+	}
+}
+
+// GetTaxableValue returns the given value pro-rated by the ownership share and by the days held during the period
+func (h Holding) GetTaxableValue(value sdk.Dec, period Period) sdk.Dec {
+	return value.Mul(h.Share).MulInt64(h.DaysHeld).QuoInt64(period.GetDays())
+}
+
+// maxTime returns the latest of the given times
+func maxTime(first, second time.Time) time.Time {
+	if first.After(second) {
+		return first
+	}
+	return second
+}
+
+// minTime returns the earliest of the given times
+func minTime(first, second time.Time) time.Time {
+	if first.Before(second) {
+		return first
+	}
+	return second
+}
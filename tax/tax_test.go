@@ -0,0 +1,199 @@
+package tax
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+
+	"github.com/riccardom/briatore/types"
+)
+
+var (
+	testLocation = time.FixedZone("CET", 60*60)
+	testAsset    = &types.Asset{Base: "uatom", Symbol: "ATOM", DenomUnits: []*banktypes.DenomUnit{{Denom: "uatom"}}}
+)
+
+func TestNewPeriod(t *testing.T) {
+	testCases := []struct {
+		name          string
+		date          time.Time
+		expectedStart time.Time
+		expectedDays  int64
+	}{
+		{
+			name:          "end of the year",
+			date:          time.Date(2023, time.December, 31, 23, 59, 59, 0, testLocation),
+			expectedStart: time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC),
+			expectedDays:  365,
+		},
+		{
+			name:          "leap year",
+			date:          time.Date(2024, time.June, 15, 12, 0, 0, 0, testLocation),
+			expectedStart: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+			expectedDays:  366,
+		},
+		{
+			name:          "year is read inside the location",
+			date:          time.Date(2023, time.December, 31, 23, 30, 0, 0, time.UTC),
+			expectedStart: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+			expectedDays:  366,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			period := NewPeriod(tc.date, testLocation)
+			if !period.Start.Equal(tc.expectedStart) {
+				t.Errorf("expected start %s, got %s", tc.expectedStart, period.Start)
+			}
+			if days := period.GetDays(); days != tc.expectedDays {
+				t.Errorf("expected %d days, got %d", tc.expectedDays, days)
+			}
+		})
+	}
+}
+
+func TestPeriod_GetHolding(t *testing.T) {
+	period := NewPeriod(time.Date(2023, time.December, 31, 23, 59, 59, 0, testLocation), testLocation)
+	origin := types.NewOrigin("cosmos", "cosmos1a", 10, time.Date(2023, time.December, 31, 0, 0, 0, 0, time.UTC))
+	amount := types.NewAmount(testAsset, origin, types.CategoryBank, sdk.NewDec(10), sdk.NewDec(100))
+
+	testCases := []struct {
+		name          string
+		holdings      []*types.TaxHoldingConfig
+		shouldErr     bool
+		expectedShare sdk.Dec
+		expectedDays  int64
+	}{
+		{
+			name:          "no config holds the whole period",
+			expectedShare: sdk.OneDec(),
+			expectedDays:  365,
+		},
+		{
+			name:          "config of another address is ignored",
+			holdings:      []*types.TaxHoldingConfig{{Address: "cosmos1b", Share: "0.5"}},
+			expectedShare: sdk.OneDec(),
+			expectedDays:  365,
+		},
+		{
+			name:          "config of another denom is ignored",
+			holdings:      []*types.TaxHoldingConfig{{Denom: "uosmo", Share: "0.5"}},
+			expectedShare: sdk.OneDec(),
+			expectedDays:  365,
+		},
+		{
+			name:          "share is read from the matching config",
+			holdings:      []*types.TaxHoldingConfig{{Address: "cosmos1a", Denom: "uatom", Share: "0.5"}},
+			expectedShare: sdk.NewDecWithPrec(5, 1),
+			expectedDays:  365,
+		},
+		{
+			name:          "held from a day of the period",
+			holdings:      []*types.TaxHoldingConfig{{HeldFrom: "2023-07-01"}},
+			expectedShare: sdk.OneDec(),
+			expectedDays:  184,
+		},
+		{
+			name:          "held until a day of the period",
+			holdings:      []*types.TaxHoldingConfig{{HeldUntil: "2023-01-31"}},
+			expectedShare: sdk.OneDec(),
+			expectedDays:  31,
+		},
+		{
+			name:          "holding dates outside the period are clamped",
+			holdings:      []*types.TaxHoldingConfig{{HeldFrom: "2022-06-01", HeldUntil: "2024-02-01"}},
+			expectedShare: sdk.OneDec(),
+			expectedDays:  365,
+		},
+		{
+			name:          "holding ended before it started is not held",
+			holdings:      []*types.TaxHoldingConfig{{HeldFrom: "2023-07-01", HeldUntil: "2023-06-30"}},
+			expectedShare: sdk.OneDec(),
+			expectedDays:  0,
+		},
+		{
+			name:      "invalid share returns error",
+			holdings:  []*types.TaxHoldingConfig{{Share: "1.5"}},
+			shouldErr: true,
+		},
+		{
+			name:      "invalid start date returns error",
+			holdings:  []*types.TaxHoldingConfig{{HeldFrom: "01/07/2023"}},
+			shouldErr: true,
+		},
+		{
+			name:      "invalid end date returns error",
+			holdings:  []*types.TaxHoldingConfig{{HeldUntil: "31/07/2023"}},
+			shouldErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			holding, err := period.GetHolding(&types.IVCAConfig{Holdings: tc.holdings}, amount)
+			if tc.shouldErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !holding.Share.Equal(tc.expectedShare) {
+				t.Errorf("expected share %s, got %s", tc.expectedShare, holding.Share)
+			}
+			if holding.DaysHeld != tc.expectedDays {
+				t.Errorf("expected %d days held, got %d", tc.expectedDays, holding.DaysHeld)
+			}
+		})
+	}
+}
+
+func TestHolding_GetTaxableValue(t *testing.T) {
+	period := NewPeriod(time.Date(2023, time.December, 31, 23, 59, 59, 0, testLocation), testLocation)
+
+	testCases := []struct {
+		name     string
+		holding  Holding
+		value    sdk.Dec
+		expected sdk.Dec
+	}{
+		{
+			name:     "fully owned for the whole period",
+			holding:  NewHolding(sdk.OneDec(), 365),
+			value:    sdk.NewDec(1000),
+			expected: sdk.NewDec(1000),
+		},
+		{
+			name:     "partially owned for the whole period",
+			holding:  NewHolding(sdk.NewDecWithPrec(5, 1), 365),
+			value:    sdk.NewDec(1000),
+			expected: sdk.NewDec(500),
+		},
+		{
+			name:     "partially owned for part of the period",
+			holding:  NewHolding(sdk.NewDecWithPrec(5, 1), 73),
+			value:    sdk.NewDec(1000),
+			expected: sdk.NewDec(100),
+		},
+		{
+			name:     "not held during the period",
+			holding:  NewHolding(sdk.OneDec(), 0),
+			value:    sdk.NewDec(1000),
+			expected: sdk.ZeroDec(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if value := tc.holding.GetTaxableValue(tc.value, period); !value.Equal(tc.expected) {
+				t.Errorf("expected taxable value %s, got %s", tc.expected, value)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path"
 	"strings"
+	"sync"
 
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
 )
@@ -17,6 +18,11 @@ const (
 	assetsListURL = "https://raw.githubusercontent.com/osmosis-labs/assetlists/main/osmosis-1/osmosis-1.assetlist.json"
 )
 
+var (
+	// assetsMutex makes sure that the assets file is not read and written concurrently
+	assetsMutex sync.Mutex
+)
+
 type Asset struct {
 	Name        string                 `json:"name"`
 	Base        string                 `json:"base"`
@@ -37,6 +43,21 @@ func (a *Asset) GetBaseNativeDenom() (nativeDenom string, found bool) {
 	return "", false
 }
 
+// HasDenom tells whether the given denom is one of the denoms (or aliases) of this asset
+func (a *Asset) HasDenom(denom string) bool {
+	for _, unit := range a.DenomUnits {
+		if unit.Denom == denom {
+			return true
+		}
+		for _, alias := range unit.Aliases {
+			if alias == denom {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (a *Asset) GetMaxExponent() uint64 {
 	var maxExponent uint64 = 0
 	for _, unit := range a.DenomUnits {
@@ -62,15 +83,8 @@ func (l Assets) GetAssetByChainName(chainName string) (asset *Asset, found bool)
 
 func (l Assets) GetAssetByCoinDenom(coinDenom string) (asset *Asset, found bool) {
 	for _, asset := range l {
-		for _, denom := range asset.DenomUnits {
-			if denom.Denom == coinDenom {
-				return asset, true
-			}
-			for _, alias := range denom.Aliases {
-				if alias == coinDenom {
-					return asset, true
-				}
-			}
+		if asset.HasDenom(coinDenom) {
+			return asset, true
 		}
 	}
 	return nil, false
@@ -84,11 +98,14 @@ type assetsResponse struct {
 
 // GetAssets returns the list of supported assets
 func GetAssets() (Assets, error) {
+	assetsMutex.Lock()
+	defer assetsMutex.Unlock()
+
 	// Read the stored assets
 	bz, err := os.ReadFile(path.Join(HomePath, assetFile))
 	if os.IsNotExist(err) {
 		// Get the assets from online
-		assets, err := RefreshAssets()
+		assets, err := refreshAssets()
 		if err != nil {
 			return nil, err
 		}
@@ -106,6 +123,15 @@ func GetAssets() (Assets, error) {
 
 // RefreshAssets gets the assets from the GitHub endpoint and caches them
 func RefreshAssets() (Assets, error) {
+	assetsMutex.Lock()
+	defer assetsMutex.Unlock()
+
+	return refreshAssets()
+}
+
+// refreshAssets gets the assets from the GitHub endpoint and caches them.
+// The caller must hold the assets mutex.
+func refreshAssets() (Assets, error) {
 	res, err := http.Get(assetsListURL)
 	if err != nil {
 		panic(err)
@@ -143,13 +169,14 @@ func writeAssets(assets Assets) error {
 	return os.WriteFile(path.Join(HomePath, assetFile), bz, 0600)
 }
 
-func GetBaseNativeDenom(chainName string) (string, error) {
+// GetBaseNativeDenom returns the base native denom of the asset having the given name
+func GetBaseNativeDenom(assetName string) (string, error) {
 	assets, err := GetAssets()
 	if err != nil {
-		return "", nil
+		return "", err
 	}
 
-	asset, found := assets.GetAssetByChainName(chainName)
+	asset, found := assets.GetAssetByChainName(assetName)
 	if !found {
 		return "", fmt.Errorf("asset not found")
 	}
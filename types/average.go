@@ -0,0 +1,126 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// ThresholdMeasure represents the measure of the holdings that is compared against a threshold
+type ThresholdMeasure string
+
+const (
+	// ThresholdMeasureAverage compares the average total value of the holdings
+	ThresholdMeasureAverage ThresholdMeasure = "average"
+
+	// ThresholdMeasurePeak compares the highest total value of the holdings
+	ThresholdMeasurePeak ThresholdMeasure = "peak"
+)
+
+// ParseThresholdMeasure parses the given value into a ThresholdMeasure
+func ParseThresholdMeasure(value string) (ThresholdMeasure, error) {
+	switch measure := ThresholdMeasure(strings.ToLower(value)); measure {
+	case ThresholdMeasureAverage, ThresholdMeasurePeak:
+		return measure, nil
+	default:
+		return "", fmt.Errorf("invalid threshold measure: %s", value)
+	}
+}
+
+// --------------------------------------------------------------------------------------------------------------------
+
+// AverageReport contains the average holdings over a period, computed by sampling them at a regular interval
+type AverageReport struct {
+	From     time.Time `yaml:"from" json:"from"`
+	To       time.Time `yaml:"to" json:"to"`
+	Interval string    `yaml:"interval" json:"interval"`
+	Samples  int64     `yaml:"samples" json:"samples"`
+
+	Assets []*AssetAverage `yaml:"assets" json:"assets"`
+
+	// Total contains the average and peak of the total value of the holdings
+	Total *AssetAverage `yaml:"total" json:"total"`
+
+	Thresholds []*ThresholdCheck `yaml:"thresholds,omitempty" json:"thresholds,omitempty"`
+	Warnings   []string          `yaml:"warnings,omitempty" json:"warnings,omitempty"`
+}
+
+// AssetAverage contains the average and peak holdings of a single asset over a period
+type AssetAverage struct {
+	Asset         string  `yaml:"asset" json:"asset"`
+	AverageAmount sdk.Dec `yaml:"averageAmount" json:"averageAmount"`
+	AverageValue  sdk.Dec `yaml:"averageValue" json:"averageValue"`
+
+	// PeakValue is the highest value of the holdings, reached at PeakDate, when they amounted to PeakAmount
+	PeakAmount sdk.Dec   `yaml:"peakAmount" json:"peakAmount"`
+	PeakValue  sdk.Dec   `yaml:"peakValue" json:"peakValue"`
+	PeakDate   time.Time `yaml:"peakDate" json:"peakDate"`
+}
+
+func NewAssetAverage(asset string) *AssetAverage {
+	return &AssetAverage{
+		Asset:         asset,
+		AverageAmount: sdk.ZeroDec(),
+		AverageValue:  sdk.ZeroDec(),
+		PeakAmount:    sdk.ZeroDec(),
+		PeakValue:     sdk.ZeroDec(),
+	}
+}
+
+// ThresholdCheck contains the result of the comparison between the holdings and a threshold
+type ThresholdCheck struct {
+	Name      string           `yaml:"name" json:"name"`
+	Measure   ThresholdMeasure `yaml:"measure" json:"measure"`
+	Threshold sdk.Dec          `yaml:"threshold" json:"threshold"`
+	Value     sdk.Dec          `yaml:"value" json:"value"`
+	Exceeded  bool             `yaml:"exceeded" json:"exceeded"`
+}
+
+func NewThresholdCheck(name string, measure ThresholdMeasure, threshold sdk.Dec, value sdk.Dec) *ThresholdCheck {
+	return &ThresholdCheck{
+		Name:      name,
+		Measure:   measure,
+		Threshold: threshold,
+		Value:     value,
+		Exceeded:  value.GT(threshold),
+	}
+}
+
+// --------------------------------------------------------------------------------------------------------------------
+// CSV Support
+
+type AssetAverageOutput struct {
+	Asset         string `json:"asset" yaml:"asset" csv:"asset"`
+	AverageAmount string `json:"average_amount" yaml:"average_amount" csv:"average_amount"`
+	AverageValue  string `json:"average_value" yaml:"average_value" csv:"average_value"`
+	PeakAmount    string `json:"peak_amount" yaml:"peak_amount" csv:"peak_amount"`
+	PeakValue     string `json:"peak_value" yaml:"peak_value" csv:"peak_value"`
+	PeakDate      string `json:"peak_date" yaml:"peak_date" csv:"peak_date"`
+}
+
+// FormatAverage formats the assets of the given report to be later printed properly,
+// adding a final row containing the total
+func FormatAverage(report *AverageReport) []AssetAverageOutput {
+	averages := make([]*AssetAverage, 0, len(report.Assets)+1)
+	averages = append(append(averages, report.Assets...), report.Total)
+
+	outputs := make([]AssetAverageOutput, len(averages))
+	for i, average := range averages {
+		outputs[i] = AssetAverageOutput{
+			Asset:         average.Asset,
+			AverageAmount: average.AverageAmount.String(),
+			AverageValue:  average.AverageValue.String(),
+			PeakAmount:    average.PeakAmount.String(),
+			PeakValue:     average.PeakValue.String(),
+			PeakDate:      average.PeakDate.Format(time.RFC3339),
+		}
+	}
+
+	// The total has no meaningful amount
+	outputs[len(outputs)-1].AverageAmount = ""
+	outputs[len(outputs)-1].PeakAmount = ""
+
+	return outputs
+}
@@ -5,17 +5,30 @@ import (
 	"os"
 	"path"
 	"strings"
+	"sync"
 	"time"
 )
 
 const (
 	cacheFileName = "cache.json"
+
+	// Day represents the duration of a day
+	Day = 24 * time.Hour
+
+	// ManualPriceProvider is the provider of the prices that have been provided manually
+	ManualPriceProvider = "manual"
+)
+
+var (
+	// cacheMutex makes sure that the cache file is not read and written concurrently
+	cacheMutex sync.Mutex
 )
 
 // Cache contains a list of [ChainName -> []CacheEntry] entries
 type Cache struct {
-	Blocks []BlockData `json:"blocks"`
-	Prices []PriceData `json:"prices"`
+	Blocks        []BlockData    `json:"blocks"`
+	Prices        []PriceData    `json:"prices"`
+	ExchangeRates []ExchangeRate `json:"exchangeRates"`
 }
 
 func readCache() (Cache, error) {
@@ -47,13 +60,21 @@ type BlockData struct {
 	ChainName string    `json:"chain"`
 	Height    int64     `json:"height"`
 	Timestamp time.Time `json:"timestamp"`
+
+	// Policy and RequestedTimestamp identify the search that resulted in this block
+	Policy             BlockPolicy `json:"policy"`
+	RequestedTimestamp time.Time   `json:"requestedTimestamp"`
 }
 
-func NewBlockData(chainName string, height int64, timestamp time.Time) BlockData {
+func NewBlockData(
+	chainName string, height int64, timestamp time.Time, policy BlockPolicy, requestedTimestamp time.Time,
+) BlockData {
 	return BlockData{
-		ChainName: chainName,
-		Timestamp: timestamp,
-		Height:    height,
+		ChainName:          chainName,
+		Timestamp:          timestamp,
+		Height:             height,
+		Policy:             policy,
+		RequestedTimestamp: requestedTimestamp,
 	}
 }
 
@@ -61,14 +82,19 @@ func (b BlockData) IsZero() bool {
 	return b.Height == 0
 }
 
-func GetBlockData(chainName string, timestamp time.Time) (data BlockData, found bool, err error) {
+// GetBlockData returns the cached block that has been chosen for the given chain, requested timestamp and policy
+func GetBlockData(chainName string, timestamp time.Time, policy BlockPolicy) (data BlockData, found bool, err error) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
 	cache, err := readCache()
 	if err != nil {
 		return
 	}
 
 	for _, block := range cache.Blocks {
-		if strings.EqualFold(block.ChainName, chainName) && IsSameDay(block.Timestamp, timestamp) {
+		if strings.EqualFold(block.ChainName, chainName) && block.Policy == policy &&
+			block.RequestedTimestamp.Equal(timestamp) {
 			return block, true, nil
 		}
 	}
@@ -77,6 +103,9 @@ func GetBlockData(chainName string, timestamp time.Time) (data BlockData, found
 }
 
 func CacheBlockData(data BlockData) error {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
 	cache, err := readCache()
 	if err != nil {
 		return err
@@ -89,29 +118,117 @@ func CacheBlockData(data BlockData) error {
 // --------------------------------------------------------------------------------------------------------------------
 
 type PriceData struct {
-	CoinGeckoID string    `json:"coinGeckoID"`
-	Price       float64   `json:"price"`
-	Timestamp   time.Time `json:"timestamp"`
-	Currency    string    `json:"currency"`
+	Provider  string    `json:"provider"`
+	Asset     string    `json:"asset"`
+	Price     float64   `json:"price"`
+	Timestamp time.Time `json:"timestamp"`
+	Currency  string    `json:"currency"`
+
+	// PriceTimestamp is the time the price refers to, which might differ from the requested timestamp
+	PriceTimestamp time.Time `json:"priceTimestamp"`
+
+	// Resolution is the time range within which the requested timestamps are considered the same
+	Resolution time.Duration `json:"resolution"`
+
+	// Manual tells whether the price has been provided manually, in which case Source describes where it comes from
+	Manual bool   `json:"manual,omitempty"`
+	Source string `json:"source,omitempty"`
+
+	// ExchangeRate is the rate used to convert the price from the currency it has been read in, if any
+	ExchangeRate *ExchangeRate `json:"exchangeRate,omitempty"`
+}
+
+func NewPriceData(
+	provider string, asset string, price float64, currency string,
+	timestamp time.Time, priceTimestamp time.Time, resolution time.Duration,
+) PriceData {
+	return PriceData{
+		Provider:       provider,
+		Asset:          asset,
+		Price:          price,
+		Currency:       currency,
+		Timestamp:      timestamp,
+		PriceTimestamp: priceTimestamp,
+		Resolution:     resolution,
+	}
 }
 
-func NewPriceData(coinGeckoID string, price float64, currency string, timestamp time.Time) PriceData {
+// NewManualPriceData returns a new daily PriceData instance for a price that has been provided manually.
+// The asset can be identified by any of its denoms.
+func NewManualPriceData(denom string, price float64, currency string, date time.Time, source string) PriceData {
 	return PriceData{
-		CoinGeckoID: coinGeckoID,
-		Price:       price,
-		Currency:    currency,
-		Timestamp:   timestamp,
+		Provider:       ManualPriceProvider,
+		Asset:          denom,
+		Price:          price,
+		Currency:       currency,
+		Timestamp:      date,
+		PriceTimestamp: date,
+		Resolution:     Day,
+		Manual:         true,
+		Source:         source,
+	}
+}
+
+// IsManualPriceOf tells whether this is a manual price of the given asset, in the given currency,
+// for the same day of the provided timestamp inside the given location
+func (p PriceData) IsManualPriceOf(asset *Asset, currency string, timestamp time.Time, location *time.Location) bool {
+	return p.Manual && (p.Asset == asset.Base || asset.HasDenom(p.Asset)) &&
+		strings.EqualFold(p.Currency, currency) && IsSameDay(p.Timestamp, timestamp, location)
+}
+
+// GetResolution returns the resolution of the price, considering entries without one as daily prices
+func (p PriceData) GetResolution() time.Duration {
+	if p.Resolution == 0 {
+		return Day
+	}
+	return p.Resolution
+}
+
+// IsInTimeRange tells whether the price can be used for the given timestamp within the provided resolution.
+// Daily prices refer to a UTC date, so they are compared using the time they refer to rather than the requested one.
+func (p PriceData) IsInTimeRange(timestamp time.Time, resolution time.Duration) bool {
+	priceTimestamp := p.Timestamp
+	if resolution >= Day && !p.PriceTimestamp.IsZero() {
+		priceTimestamp = p.PriceTimestamp
+	}
+	return IsSameTimeRange(priceTimestamp, timestamp, resolution)
+}
+
+// GetPriceData returns the cached price of the asset having the given base denom in the given currency
+// that can be used for the given timestamp within the provided resolution.
+// Only prices having the same or a finer resolution are returned.
+func GetPriceData(asset string, currency string, timestamp time.Time, resolution time.Duration) (data PriceData, found bool, err error) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	cache, err := readCache()
+	if err != nil {
+		return
+	}
+
+	for _, price := range cache.Prices {
+		if !price.Manual && price.Asset == asset && price.Currency == currency && price.GetResolution() <= resolution &&
+			price.IsInTimeRange(timestamp, resolution) {
+			return price, true, nil
+		}
 	}
+
+	return PriceData{}, false, nil
 }
 
-func GetPriceData(coinGeckoID string, currency string, timestamp time.Time) (data PriceData, found bool, err error) {
+// GetManualPriceData returns the cached manual price of the given asset in the given currency
+// for the same day of the provided timestamp inside the given location
+func GetManualPriceData(asset *Asset, currency string, timestamp time.Time, location *time.Location) (data PriceData, found bool, err error) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
 	cache, err := readCache()
 	if err != nil {
 		return
 	}
 
 	for _, price := range cache.Prices {
-		if price.CoinGeckoID == coinGeckoID && price.Currency == currency && IsSameDay(price.Timestamp, timestamp) {
+		if price.IsManualPriceOf(asset, currency, timestamp, location) {
 			return price, true, nil
 		}
 	}
@@ -119,7 +236,41 @@ func GetPriceData(coinGeckoID string, currency string, timestamp time.Time) (dat
 	return PriceData{}, false, nil
 }
 
+// CacheManualPrices stores the given manual prices, replacing the cached manual prices of the same denoms and
+// currencies for the same days inside the given location
+func CacheManualPrices(prices []PriceData, location *time.Location) error {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	cache, err := readCache()
+	if err != nil {
+		return err
+	}
+
+	var cachedPrices []PriceData
+	for _, cached := range cache.Prices {
+		replaced := false
+		for _, price := range prices {
+			if cached.Manual && cached.Asset == price.Asset && strings.EqualFold(cached.Currency, price.Currency) &&
+				IsSameDay(cached.Timestamp, price.Timestamp, location) {
+				replaced = true
+				break
+			}
+		}
+
+		if !replaced {
+			cachedPrices = append(cachedPrices, cached)
+		}
+	}
+
+	cache.Prices = append(cachedPrices, prices...)
+	return writeCache(cache)
+}
+
 func CachePriceData(data PriceData) error {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
 	cache, err := readCache()
 	if err != nil {
 		return err
@@ -131,7 +282,79 @@ func CachePriceData(data PriceData) error {
 
 // --------------------------------------------------------------------------------------------------------------------
 
-func IsSameDay(first, second time.Time) bool {
+// GetExchangeRate returns the most recent cached exchange rate between the given currencies that has been published
+// on or before the day of the given timestamp inside the provided location.
+// Rates older than a few days are ignored, so that missing rates are not silently replaced by outdated ones.
+func GetExchangeRate(from, to string, timestamp time.Time, location *time.Location) (rate ExchangeRate, found bool, err error) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	cache, err := readCache()
+	if err != nil {
+		return
+	}
+
+	date := GetDay(timestamp, location)
+	oldestDate := date.AddDate(0, 0, -maxExchangeRateAge)
+	for _, cached := range cache.ExchangeRates {
+		if !cached.Converts(from, to) || cached.Date.After(date) || cached.Date.Before(oldestDate) {
+			continue
+		}
+
+		if !found || cached.Date.After(rate.Date) {
+			rate, found = cached, true
+		}
+	}
+
+	return rate, found, nil
+}
+
+// CacheExchangeRates stores the given exchange rates, replacing the cached ones having the same currencies,
+// date and source
+func CacheExchangeRates(rates []ExchangeRate) error {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	cache, err := readCache()
+	if err != nil {
+		return err
+	}
+
+	type rateKey struct {
+		base, quote, source string
+		date                time.Time
+	}
+
+	imported := map[rateKey]bool{}
+	for _, rate := range rates {
+		imported[rateKey{rate.Base, rate.Quote, rate.Source, rate.Date}] = true
+	}
+
+	var cachedRates []ExchangeRate
+	for _, cached := range cache.ExchangeRates {
+		if !imported[rateKey{cached.Base, cached.Quote, cached.Source, cached.Date}] {
+			cachedRates = append(cachedRates, cached)
+		}
+	}
+
+	cache.ExchangeRates = append(cachedRates, rates...)
+	return writeCache(cache)
+}
+
+// --------------------------------------------------------------------------------------------------------------------
+
+// IsSameTimeRange tells whether the given instants fall inside the same range of the given resolution.
+// Resolutions of one day or more compare the UTC dates, which are the ones daily provider prices refer to.
+func IsSameTimeRange(first, second time.Time, resolution time.Duration) bool {
+	if resolution >= Day {
+		return IsSameDay(first, second, time.UTC)
+	}
+	return first.Truncate(resolution).Equal(second.Truncate(resolution))
+}
+
+// IsSameDay tells whether the given instants fall inside the same day of the provided location
+func IsSameDay(first, second time.Time, location *time.Location) bool {
+	first, second = first.In(location), second.In(location)
 	return first.Year() == second.Year() &&
 		first.Month() == second.Month() &&
 		first.Day() == second.Day()
@@ -0,0 +1,113 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsSameTimeRange(t *testing.T) {
+	location := time.FixedZone("CET", 60*60)
+
+	testCases := []struct {
+		name       string
+		first      time.Time
+		second     time.Time
+		resolution time.Duration
+		expected   bool
+	}{
+		{
+			name:       "same UTC day that is different inside another location",
+			first:      time.Date(2023, time.December, 31, 22, 0, 0, 0, time.UTC),
+			second:     time.Date(2023, time.December, 31, 23, 30, 0, 0, time.UTC),
+			resolution: Day,
+			expected:   true,
+		},
+		{
+			name:       "different UTC days that are the same inside another location",
+			first:      time.Date(2023, time.December, 31, 23, 30, 0, 0, time.UTC),
+			second:     time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC),
+			resolution: Day,
+			expected:   false,
+		},
+		{
+			name:       "days are compared in UTC regardless of the instants location",
+			first:      time.Date(2024, time.January, 1, 0, 30, 0, 0, location),
+			second:     time.Date(2023, time.December, 31, 12, 0, 0, 0, time.UTC),
+			resolution: Day,
+			expected:   true,
+		},
+		{
+			name:       "same hour",
+			first:      time.Date(2023, time.December, 31, 10, 5, 0, 0, time.UTC),
+			second:     time.Date(2023, time.December, 31, 10, 55, 0, 0, time.UTC),
+			resolution: time.Hour,
+			expected:   true,
+		},
+		{
+			name:       "different hours",
+			first:      time.Date(2023, time.December, 31, 10, 55, 0, 0, time.UTC),
+			second:     time.Date(2023, time.December, 31, 11, 5, 0, 0, time.UTC),
+			resolution: time.Hour,
+			expected:   false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := IsSameTimeRange(tc.first, tc.second, tc.resolution); result != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestPriceData_IsInTimeRange(t *testing.T) {
+	location := time.FixedZone("CET", 60*60)
+	requested := time.Date(2023, time.December, 31, 23, 59, 59, 0, location)
+	daily := time.Date(2023, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name       string
+		price      PriceData
+		timestamp  time.Time
+		resolution time.Duration
+		expected   bool
+	}{
+		{
+			name:       "daily price matches timestamps of its UTC date",
+			price:      NewPriceData("coingecko", "uatom", 10, "eur", requested, daily, Day),
+			timestamp:  time.Date(2023, time.December, 31, 12, 0, 0, 0, location),
+			resolution: Day,
+			expected:   true,
+		},
+		{
+			name:       "daily price does not match timestamps of the next UTC date",
+			price:      NewPriceData("coingecko", "uatom", 10, "eur", requested, daily, Day),
+			timestamp:  time.Date(2024, time.January, 1, 1, 30, 0, 0, location),
+			resolution: Day,
+			expected:   false,
+		},
+		{
+			name:       "daily price without price timestamp uses the requested one",
+			price:      PriceData{Asset: "uatom", Price: 10, Currency: "eur", Timestamp: requested},
+			timestamp:  time.Date(2023, time.December, 31, 12, 0, 0, 0, time.UTC),
+			resolution: Day,
+			expected:   true,
+		},
+		{
+			name:       "finer resolutions compare the requested timestamps",
+			price:      NewPriceData("coingecko", "uatom", 10, "eur", requested, daily, time.Minute),
+			timestamp:  requested.Add(-10 * time.Second),
+			resolution: time.Minute,
+			expected:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := tc.price.IsInTimeRange(tc.timestamp, tc.resolution); result != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, result)
+			}
+		})
+	}
+}
@@ -1,24 +1,50 @@
 package types
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type HistoryResponse struct {
 	MarketData *MarketData `json:"market_data"`
 }
 
-func (h HistoryResponse) GetCoinPrice(currency string) (float64, error) {
+// GetCoinPrice returns the price of the coin in the given currency.
+// If the response contains no market data (e.g. the coin was not traded on that date), found is false.
+func (h HistoryResponse) GetCoinPrice(currency string) (price float64, found bool, err error) {
 	if h.MarketData == nil {
-		return 0, nil
+		return 0, false, nil
 	}
 
 	price, ok := h.MarketData.CurrentPrice[currency]
 	if !ok {
-		return 0, fmt.Errorf("invalid currency: %s", currency)
+		return 0, false, fmt.Errorf("invalid currency: %s", currency)
 	}
 
-	return price, nil
+	return price, true, nil
 }
 
 type MarketData struct {
 	CurrentPrice map[string]float64 `json:"current_price"`
 }
+
+// MarketChartResponse contains the data returned by the market chart APIs
+type MarketChartResponse struct {
+	// Prices contains a list of [timestamp in milliseconds, price] pairs
+	Prices [][2]float64 `json:"prices"`
+}
+
+// GetClosestPrice returns the price closest to the given timestamp, along with the time it refers to.
+// If no price is present, found is false.
+func (m MarketChartResponse) GetClosestPrice(timestamp time.Time) (price float64, priceTimestamp time.Time, found bool) {
+	var closestDistance time.Duration
+	for _, point := range m.Prices {
+		pointTimestamp := time.UnixMilli(int64(point[0])).UTC()
+		distance := pointTimestamp.Sub(timestamp).Abs()
+		if !found || distance < closestDistance {
+			price, priceTimestamp, closestDistance, found = point[1], pointTimestamp, distance, true
+		}
+	}
+
+	return price, priceTimestamp, found
+}
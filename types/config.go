@@ -1,9 +1,13 @@
 package types
 
 import (
+	"fmt"
 	"os"
 	"path"
+	"strings"
+	"time"
 
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
@@ -18,16 +22,589 @@ type Config struct {
 	Chains []*ChainConfig `yaml:"chains"`
 }
 
+// GetChainConfig returns the configuration of the chain having the given name
+func (c *Config) GetChainConfig(name string) (chain *ChainConfig, found bool) {
+	for _, chain := range c.Chains {
+		if strings.EqualFold(chain.Name, name) {
+			return chain, true
+		}
+	}
+	return nil, false
+}
+
 type ReportConfig struct {
-	Currency string `yaml:"currency"`
+	Currency        string                 `yaml:"currency"`
+	LiquidStaking   []*LiquidStakingConfig `yaml:"liquidStaking"`
+	Concurrency     *ConcurrencyConfig     `yaml:"concurrency"`
+	BlockSearch     BlockSearchStrategy    `yaml:"blockSearch"`
+	BlockPolicy     BlockPolicy            `yaml:"blockPolicy"`
+	MaxBlockGap     time.Duration          `yaml:"maxBlockGap"`
+	Timezone        string                 `yaml:"timezone"`
+	PriceProviders  []string               `yaml:"priceProviders"`
+	OsmosisTwap     *OsmosisTwapConfig     `yaml:"osmosisTwap"`
+	CoinGecko       *CoinGeckoConfig       `yaml:"coingecko"`
+	PriceResolution time.Duration          `yaml:"priceResolution"`
+	ManualPrices    []*ManualPriceConfig   `yaml:"manualPrices"`
+	FX              *FXConfig              `yaml:"fx"`
+	Tax             *TaxConfig             `yaml:"tax"`
+	Average         *AverageConfig         `yaml:"average"`
+}
+
+// GetAverageConfig returns the average holdings config, using the default values for the fields that are not set
+func (c *ReportConfig) GetAverageConfig() *AverageConfig {
+	config := DefaultAverageConfig()
+	if c.Average == nil {
+		return config
+	}
+
+	config.Thresholds = c.Average.Thresholds
+	if c.Average.Interval != 0 {
+		config.Interval = c.Average.Interval
+	}
+	return config
+}
+
+// GetTaxConfig returns the taxes config, or the default one if not set
+func (c *ReportConfig) GetTaxConfig() *TaxConfig {
+	if c.Tax == nil {
+		return &TaxConfig{}
+	}
+	return c.Tax
+}
+
+// GetFXConfig returns the exchange rates config, or nil if prices should be read directly in the report currency
+func (c *ReportConfig) GetFXConfig() *FXConfig {
+	if c.FX == nil {
+		return nil
+	}
+
+	config := DefaultFXConfig()
+	if c.FX.BaseCurrency != "" {
+		config.BaseCurrency = c.FX.BaseCurrency
+	}
+	return config
+}
+
+// GetPriceResolution returns the time range within which prices requested for different times are considered the same.
+// If not set, prices are daily unless CoinGecko is set to get exact-time prices.
+func (c *ReportConfig) GetPriceResolution() time.Duration {
+	if c.PriceResolution != 0 {
+		return c.PriceResolution
+	}
+
+	if c.GetCoinGeckoConfig().GetMode() == CoinGeckoModeExact {
+		return time.Minute
+	}
+
+	return Day
+}
+
+// GetCoinGeckoConfig returns the CoinGecko config, or the default one if not set
+func (c *ReportConfig) GetCoinGeckoConfig() *CoinGeckoConfig {
+	if c.CoinGecko == nil {
+		return &CoinGeckoConfig{}
+	}
+	return c.CoinGecko
+}
+
+// GetOsmosisTwapConfig returns the Osmosis TWAP config, using the default values for the fields that are not set
+func (c *ReportConfig) GetOsmosisTwapConfig() *OsmosisTwapConfig {
+	config := DefaultOsmosisTwapConfig()
+	if c.OsmosisTwap == nil {
+		return config
+	}
+
+	config.Pools = c.OsmosisTwap.Pools
+	if c.OsmosisTwap.Chain != "" {
+		config.Chain = c.OsmosisTwap.Chain
+	}
+	if c.OsmosisTwap.QuoteDenom != "" {
+		config.QuoteDenom = c.OsmosisTwap.QuoteDenom
+	}
+	if c.OsmosisTwap.Window != 0 {
+		config.Window = c.OsmosisTwap.Window
+	}
+	return config
+}
+
+// GetPriceProviders returns the names of the price providers to be used, in order of preference,
+// or the default ones if not set
+func (c *ReportConfig) GetPriceProviders() []string {
+	if len(c.PriceProviders) == 0 {
+		return []string{"coingecko"}
+	}
+	return c.PriceProviders
+}
+
+// GetLocation returns the location identified by the configured timezone, or UTC if not set
+func (c *ReportConfig) GetLocation() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.UTC, nil
+	}
+
+	location, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %s: %w", c.Timezone, err)
+	}
+
+	return location, nil
+}
+
+// GetBlockPolicy returns the policy used to choose the block of a report, or the default one if not set
+func (c *ReportConfig) GetBlockPolicy() BlockPolicy {
+	if c.BlockPolicy == "" {
+		return BlockPolicyNearest
+	}
+	return c.BlockPolicy
+}
+
+// GetMaxBlockGap returns the maximum tolerated distance between the chosen block and the requested time,
+// or the default one if not set
+func (c *ReportConfig) GetMaxBlockGap() time.Duration {
+	if c.MaxBlockGap == 0 {
+		return time.Hour
+	}
+	return c.MaxBlockGap
+}
+
+// GetBlockSearchStrategy returns the strategy used to search blocks, or the default one if not set
+func (c *ReportConfig) GetBlockSearchStrategy() BlockSearchStrategy {
+	if c.BlockSearch == "" {
+		return BlockSearchInterpolation
+	}
+	return c.BlockSearch
+}
+
+// GetConcurrency returns the concurrency config, using the default value of each field that is not set
+func (c *ReportConfig) GetConcurrency() *ConcurrencyConfig {
+	config := DefaultConcurrencyConfig()
+	if c.Concurrency == nil {
+		return config
+	}
+
+	if c.Concurrency.MaxWorkers > 0 {
+		config.MaxWorkers = c.Concurrency.MaxWorkers
+	}
+	if c.Concurrency.MaxWorkersPerChain > 0 {
+		config.MaxWorkersPerChain = c.Concurrency.MaxWorkersPerChain
+	}
+	return config
+}
+
+// GetLiquidStakingConfig returns the liquid staking configuration associated with the given asset, if any
+func (c *ReportConfig) GetLiquidStakingConfig(asset *Asset) (config *LiquidStakingConfig, found bool) {
+	for _, config := range c.LiquidStaking {
+		if asset.HasDenom(config.Denom) {
+			return config, true
+		}
+	}
+	return nil, false
+}
+
+// FXConfig contains the data used to convert prices into the report currency using the official exchange rates
+type FXConfig struct {
+	// BaseCurrency is the currency the prices are read in before being converted
+	BaseCurrency string `yaml:"baseCurrency"`
+}
+
+func DefaultFXConfig() *FXConfig {
+	return &FXConfig{
+		BaseCurrency: "usd",
+	}
+}
+
+// TaxConfig contains the data used to compute the taxes due on the reported values
+type TaxConfig struct {
+	IVCA *IVCAConfig `yaml:"ivca"`
+}
+
+// GetIVCAConfig returns the IVCA config, or the default one if not set
+func (c *TaxConfig) GetIVCAConfig() *IVCAConfig {
+	if c.IVCA == nil {
+		return &IVCAConfig{}
+	}
+	return c.IVCA
+}
+
+// IVCAConfig contains the data used to compute the Italian wealth tax on crypto-assets
+type IVCAConfig struct {
+	// Rate is the rate applied to the end-of-period values (defaults to 0.002)
+	Rate string `yaml:"rate"`
+
+	// Holdings allows to pro-rate the values of specific addresses or assets
+	Holdings []*TaxHoldingConfig `yaml:"holdings"`
+}
+
+// GetRate returns the rate applied to the end-of-period values, or the default one if not set
+func (c *IVCAConfig) GetRate() (sdk.Dec, error) {
+	if c.Rate == "" {
+		return sdk.NewDecWithPrec(2, 3), nil
+	}
+
+	rate, err := sdk.NewDecFromStr(c.Rate)
+	if err != nil {
+		return sdk.Dec{}, fmt.Errorf("invalid IVCA rate %s: %w", c.Rate, err)
+	}
+
+	return rate, nil
+}
+
+// GetHoldingConfig returns the first holding config matching the given address and asset, if any
+func (c *IVCAConfig) GetHoldingConfig(address string, asset *Asset) (config *TaxHoldingConfig, found bool) {
+	for _, config := range c.Holdings {
+		if (config.Address == "" || config.Address == address) && (config.Denom == "" || asset.HasDenom(config.Denom)) {
+			return config, true
+		}
+	}
+	return nil, false
+}
+
+// TaxHoldingConfig describes how the holdings of an address or of an asset should be pro-rated.
+// When both the address and the denom are set, only the holdings of such asset by such address are matched.
+type TaxHoldingConfig struct {
+	Address string `yaml:"address"`
+	Denom   string `yaml:"denom"`
+
+	// Share is the ownership share of the holdings (defaults to 1)
+	Share string `yaml:"share"`
+
+	// HeldFrom and HeldUntil are the first and last days the holdings have been held.
+	// If not set, the holdings are considered held since the start and until the end of the period
+	HeldFrom  string `yaml:"heldFrom"`
+	HeldUntil string `yaml:"heldUntil"`
+}
+
+// GetShare returns the ownership share of the holdings
+func (c *TaxHoldingConfig) GetShare() (sdk.Dec, error) {
+	if c.Share == "" {
+		return sdk.OneDec(), nil
+	}
+
+	share, err := sdk.NewDecFromStr(c.Share)
+	if err != nil {
+		return sdk.Dec{}, fmt.Errorf("invalid ownership share %s: %w", c.Share, err)
+	}
+
+	if share.IsNegative() || share.GT(sdk.OneDec()) {
+		return sdk.Dec{}, fmt.Errorf("ownership share must be between 0 and 1: %s", c.Share)
+	}
+
+	return share, nil
+}
+
+// AverageConfig contains the data used to compute the average holdings over a period
+type AverageConfig struct {
+	// Interval is the time between two consecutive samples of the holdings (defaults to 24h)
+	Interval time.Duration `yaml:"interval"`
+
+	// Thresholds contains the values the holdings are checked against
+	Thresholds []*ThresholdConfig `yaml:"thresholds"`
+}
+
+func DefaultAverageConfig() *AverageConfig {
+	return &AverageConfig{
+		Interval: Day,
+	}
 }
 
+// ThresholdConfig contains a value the total value of the holdings is checked against
+type ThresholdConfig struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+
+	// Measure is the measure of the holdings compared against the value (defaults to average)
+	Measure string `yaml:"measure"`
+}
+
+// GetValue returns the value of the threshold
+func (c *ThresholdConfig) GetValue() (sdk.Dec, error) {
+	value, err := sdk.NewDecFromStr(c.Value)
+	if err != nil {
+		return sdk.Dec{}, fmt.Errorf("invalid value of threshold %s: %w", c.Name, err)
+	}
+	return value, nil
+}
+
+// GetMeasure returns the measure of the holdings compared against the value, or the default one if not set
+func (c *ThresholdConfig) GetMeasure() (ThresholdMeasure, error) {
+	if c.Measure == "" {
+		return ThresholdMeasureAverage, nil
+	}
+	return ParseThresholdMeasure(c.Measure)
+}
+
+// ManualPriceConfig contains a documented price of an asset, which is used instead of the ones of the providers
+type ManualPriceConfig struct {
+	// Denom is any of the denoms of the asset
+	Denom string `yaml:"denom" csv:"denom"`
+
+	// Date is the day the price refers to
+	Date string `yaml:"date" csv:"date"`
+
+	// Currency is the currency the price is measured in (defaults to the report currency)
+	Currency string  `yaml:"currency" csv:"currency"`
+	Price    float64 `yaml:"price" csv:"price"`
+
+	// Source is a note describing where the price has been taken from
+	Source string `yaml:"source" csv:"source"`
+}
+
+// GetPriceData returns the price data represented by this config.
+// The date is parsed inside the given location, and the given currency is used if none is set.
+func (c *ManualPriceConfig) GetPriceData(location *time.Location, defaultCurrency string) (PriceData, error) {
+	if c.Denom == "" {
+		return PriceData{}, fmt.Errorf("missing denom of manual price")
+	}
+
+	if c.Price < 0 {
+		return PriceData{}, fmt.Errorf("invalid manual price of %s: %f", c.Denom, c.Price)
+	}
+
+	date, err := ParseDate(c.Date, location)
+	if err != nil {
+		return PriceData{}, fmt.Errorf("invalid date of %s manual price: %w", c.Denom, err)
+	}
+
+	currency := c.Currency
+	if currency == "" {
+		currency = defaultCurrency
+	}
+
+	return NewManualPriceData(c.Denom, c.Price, currency, date, c.Source), nil
+}
+
+// ConcurrencyConfig contains the limits of the amounts that are fetched at the same time
+type ConcurrencyConfig struct {
+	// MaxWorkers is the maximum number of addresses that are fetched at the same time across all chains
+	MaxWorkers int `yaml:"maxWorkers"`
+
+	// MaxWorkersPerChain is the maximum number of addresses that are fetched at the same time on a single chain
+	MaxWorkersPerChain int `yaml:"maxWorkersPerChain"`
+}
+
+func DefaultConcurrencyConfig() *ConcurrencyConfig {
+	return &ConcurrencyConfig{
+		MaxWorkers:         8,
+		MaxWorkersPerChain: 2,
+	}
+}
+
+// BlockSearchStrategy represents the algorithm used to find the block closest to a timestamp
+type BlockSearchStrategy string
+
+const (
+	// BlockSearchInterpolation estimates the searched height using the average block time between
+	// the search bounds, falling back to bisection when the estimates are not accurate enough
+	BlockSearchInterpolation BlockSearchStrategy = "interpolation"
+
+	// BlockSearchBinary always bisects the search bounds
+	BlockSearchBinary BlockSearchStrategy = "binary"
+)
+
+// CoinGeckoPlan represents the CoinGecko API plan that is used
+type CoinGeckoPlan string
+
+const (
+	CoinGeckoPlanPublic CoinGeckoPlan = "public"
+	CoinGeckoPlanDemo   CoinGeckoPlan = "demo"
+	CoinGeckoPlanPro    CoinGeckoPlan = "pro"
+)
+
+// CoinGeckoMode represents the way in which the CoinGecko prices are read
+type CoinGeckoMode string
+
+const (
+	// CoinGeckoModeDaily uses the daily historical snapshot, which contains the price at 00:00 UTC
+	CoinGeckoModeDaily CoinGeckoMode = "daily"
+
+	// CoinGeckoModeExact uses the market chart around the requested time, picking its closest data point
+	CoinGeckoModeExact CoinGeckoMode = "exact"
+)
+
+// CoinGeckoConfig contains the data used to query the CoinGecko APIs
+type CoinGeckoConfig struct {
+	// Mode is the way in which the prices are read (defaults to daily)
+	Mode CoinGeckoMode `yaml:"mode"`
+
+	// Plan is the API plan associated with the API key (defaults to public if no key is set, or demo otherwise)
+	Plan   CoinGeckoPlan `yaml:"plan"`
+	APIKey string        `yaml:"apiKey"`
+
+	// RequestsPerMinute is the maximum number of requests sent each minute (defaults to the plan limit)
+	RequestsPerMinute int `yaml:"requestsPerMinute"`
+
+	// Timeout is the maximum duration of each request (defaults to 30s)
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// GetMode returns the way in which the prices are read
+func (c *CoinGeckoConfig) GetMode() CoinGeckoMode {
+	if c.Mode == "" {
+		return CoinGeckoModeDaily
+	}
+	return c.Mode
+}
+
+// GetPlan returns the API plan that is used
+func (c *CoinGeckoConfig) GetPlan() CoinGeckoPlan {
+	switch {
+	case c.Plan != "":
+		return c.Plan
+	case c.APIKey != "":
+		return CoinGeckoPlanDemo
+	default:
+		return CoinGeckoPlanPublic
+	}
+}
+
+// GetRequestsPerMinute returns the maximum number of requests sent each minute
+func (c *CoinGeckoConfig) GetRequestsPerMinute() int {
+	if c.RequestsPerMinute > 0 {
+		return c.RequestsPerMinute
+	}
+
+	switch c.GetPlan() {
+	case CoinGeckoPlanPro:
+		return 500
+	case CoinGeckoPlanDemo:
+		return 30
+	default:
+		return 10
+	}
+}
+
+// GetTimeout returns the maximum duration of each request
+func (c *CoinGeckoConfig) GetTimeout() time.Duration {
+	if c.Timeout == 0 {
+		return 30 * time.Second
+	}
+	return c.Timeout
+}
+
+// OsmosisTwapConfig contains the data needed to price the assets using the TWAP of the Osmosis pools
+type OsmosisTwapConfig struct {
+	// Chain is the name of the Osmosis chain inside the config
+	Chain string `yaml:"chain"`
+
+	// QuoteDenom is the denom of the asset the prices are measured against (e.g. uosmo or the USDC denom)
+	QuoteDenom string `yaml:"quoteDenom"`
+
+	// Window is the time range over which the TWAP is computed, ending at the report block
+	Window time.Duration `yaml:"window"`
+
+	// Pools contains the pools to be used for the given denoms.
+	// Denoms without a pool use the one having the highest liquidity of the quote asset
+	Pools []*TwapPoolConfig `yaml:"pools"`
+}
+
+func DefaultOsmosisTwapConfig() *OsmosisTwapConfig {
+	return &OsmosisTwapConfig{
+		Chain:      "Osmosis",
+		QuoteDenom: "uosmo",
+		Window:     time.Hour,
+	}
+}
+
+// GetPoolID returns the id of the pool configured for the given denom, if any
+func (c *OsmosisTwapConfig) GetPoolID(denom string) (poolID uint64, found bool) {
+	for _, pool := range c.Pools {
+		if pool.Denom == denom {
+			return pool.PoolID, true
+		}
+	}
+	return 0, false
+}
+
+// TwapPoolConfig contains the pool used to compute the TWAP of a denom
+type TwapPoolConfig struct {
+	Denom  string `yaml:"denom"`
+	PoolID uint64 `yaml:"poolId"`
+}
+
+// LiquidStakingConfig contains the data needed to value a liquid staking token using the redemption rate
+// of the protocol that issued it, instead of its market price
+type LiquidStakingConfig struct {
+	// Denom is the denom of the liquid staking token (e.g. stuatom)
+	Denom string `yaml:"denom"`
+
+	// Chain is the name of the chain where the liquid staking protocol lives (e.g. Stride)
+	Chain string `yaml:"chain"`
+
+	// HostZone is the chain id of the zone whose tokens are liquid staked (e.g. cosmoshub-4)
+	HostZone string `yaml:"hostZone"`
+}
+
+const (
+	// osmosisModuleName is the name of the module reporter that reads the Osmosis lockups, LP and superfluid amounts
+	osmosisModuleName = "osmosis"
+)
+
 type ChainConfig struct {
-	Name           string `yaml:"name"`
-	RPCAddress     string `yaml:"rpcAddress"`
-	AssetName      string `yaml:"asset"`
-	Bech32Prefix   string `yaml:"bech32Prefix"`
-	MinBlockHeight int64  `yaml:"minBlockHeight"`
+	Name           string        `yaml:"name"`
+	RPCAddress     string        `yaml:"rpcAddress"`
+	RPCAddresses   []string      `yaml:"rpcAddresses"`
+	AssetName      string        `yaml:"asset"`
+	Bech32Prefix   string        `yaml:"bech32Prefix"`
+	MinBlockHeight int64         `yaml:"minBlockHeight"`
+	CW20Tokens     []*CW20Config `yaml:"cw20"`
+	Modules        []string      `yaml:"modules"`
+	Eras           []*EraConfig  `yaml:"eras"`
+}
+
+// GetEras returns the eras of the chain history, in chronological order.
+// If no era is configured, a single era using the chain RPC addresses is returned instead.
+func (c *ChainConfig) GetEras() []*EraConfig {
+	if len(c.Eras) == 0 {
+		return []*EraConfig{{
+			RPCAddresses: c.GetRPCAddresses(),
+			StartHeight:  c.MinBlockHeight,
+		}}
+	}
+	return c.Eras
+}
+
+// GetRPCAddresses returns all the RPC addresses configured for this chain, in order of preference
+func (c *ChainConfig) GetRPCAddresses() []string {
+	if c.RPCAddress == "" {
+		return c.RPCAddresses
+	}
+	return append([]string{c.RPCAddress}, c.RPCAddresses...)
+}
+
+// GetModules returns the names of the chain-specific modules whose amounts should be included in the report.
+// If no module is configured, chains whose name contains osmosis enable the osmosis module by default so that existing
+// configs do not lose their lockup, LP and superfluid amounts. Set modules to an empty list to disable it instead.
+func (c *ChainConfig) GetModules() []string {
+	if c.Modules == nil && strings.Contains(strings.ToLower(c.Name), osmosisModuleName) {
+		return []string{osmosisModuleName}
+	}
+	return c.Modules
+}
+
+// GetCW20Assets returns the assets representing the CW20 tokens configured for this chain
+func (c *ChainConfig) GetCW20Assets() Assets {
+	assets := make(Assets, len(c.CW20Tokens))
+	for i, token := range c.CW20Tokens {
+		assets[i] = token.GetAsset()
+	}
+	return assets
+}
+
+// EraConfig represents a period of the chain history that is served by its own endpoints,
+// such as the one before a chain restarted from a new genesis with a different chain id.
+// Each era ends when the following one starts.
+type EraConfig struct {
+	ChainID      string   `yaml:"chainId"`
+	RPCAddresses []string `yaml:"rpcAddresses"`
+
+	// StartTime is the time at which the era started.
+	// If not set, it is read from the block at the start height
+	StartTime time.Time `yaml:"startTime"`
+
+	// StartHeight and EndHeight are the heights of the first and last blocks of the era.
+	// If not set, the earliest and latest heights available on the endpoints are used
+	StartHeight int64 `yaml:"startHeight"`
+	EndHeight   int64 `yaml:"endHeight"`
 }
 
 type AccountConfig struct {
@@ -57,5 +634,19 @@ func ReadConfig(cmd *cobra.Command) (*Config, error) {
 		return nil, err
 	}
 
+	if cfg.Report != nil && cfg.Report.BlockPolicy != "" {
+		cfg.Report.BlockPolicy, err = ParseBlockPolicy(string(cfg.Report.BlockPolicy))
+		if err != nil {
+			return nil, fmt.Errorf("error while reading the block policy: %w", err)
+		}
+	}
+
+	for _, chain := range cfg.Chains {
+		if chain.Modules == nil && len(chain.GetModules()) > 0 {
+			log.Warn().Str("chain", chain.Name).Strs("modules", chain.GetModules()).
+				Msg("no modules configured, enabling the default ones")
+		}
+	}
+
 	return &cfg, nil
 }
@@ -0,0 +1,79 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	sdkmath "cosmossdk.io/math"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+const (
+	cw20DenomPrefix = "cw20:"
+)
+
+// CW20Config contains the data of a CW20 token contract whose balances should be included inside the report
+type CW20Config struct {
+	Contract    string `yaml:"contract"`
+	Name        string `yaml:"name"`
+	Symbol      string `yaml:"symbol"`
+	Decimals    uint32 `yaml:"decimals"`
+	CoingeckoID string `yaml:"coingeckoId"`
+}
+
+// IsCW20Denom tells whether the given denom represents a CW20 token
+func IsCW20Denom(denom string) bool {
+	return strings.HasPrefix(denom, cw20DenomPrefix)
+}
+
+// GetDenom returns the denom that is used to represent the token balances as coins
+func (c *CW20Config) GetDenom() string {
+	return cw20DenomPrefix + c.Contract
+}
+
+// GetAsset returns the Asset that represents this CW20 token
+func (c *CW20Config) GetAsset() *Asset {
+	return &Asset{
+		Name:        c.Name,
+		Base:        c.GetDenom(),
+		Symbol:      c.Symbol,
+		CoingeckoID: c.CoingeckoID,
+		DenomUnits: []*banktypes.DenomUnit{
+			{Denom: c.GetDenom(), Exponent: 0},
+			{Denom: strings.ToLower(c.Symbol), Exponent: c.Decimals},
+		},
+	}
+}
+
+// --------------------------------------------------------------------------------------------------------------------
+
+// CW20BalanceQuery represents the smart query used to get the balance of an address from a CW20 contract
+type CW20BalanceQuery struct {
+	Balance CW20BalanceQueryData `json:"balance"`
+}
+
+type CW20BalanceQueryData struct {
+	Address string `json:"address"`
+}
+
+func NewCW20BalanceQuery(address string) CW20BalanceQuery {
+	return CW20BalanceQuery{
+		Balance: CW20BalanceQueryData{
+			Address: address,
+		},
+	}
+}
+
+// CW20BalanceResponse represents the response returned by a CW20 contract when querying a balance
+type CW20BalanceResponse struct {
+	Balance string `json:"balance"`
+}
+
+// GetAmount returns the balance amount as an integer
+func (r CW20BalanceResponse) GetAmount() (sdkmath.Int, error) {
+	amount, ok := sdkmath.NewIntFromString(r.Balance)
+	if !ok {
+		return sdkmath.Int{}, fmt.Errorf("invalid cw20 balance: %s", r.Balance)
+	}
+	return amount, nil
+}
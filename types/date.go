@@ -0,0 +1,54 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	dateLayout     = "2006-01-02"
+	dateTimeLayout = "2006-01-02T15:04:05"
+	yearLayout     = "2006"
+)
+
+// ParseDate parses the given value into the instant a report should be computed for, resolving it
+// inside the provided location. The supported values are:
+//   - RFC3339 timestamps (e.g. 2023-12-31T23:59:59Z), which are used as they are;
+//   - timestamps without a timezone (e.g. 2023-12-31T23:59:59), which are read inside the location;
+//   - dates (e.g. 2023-12-31), which are resolved to the end of the day inside the location;
+//   - years (e.g. 2023), which are resolved to the end of the last day of the year inside the location.
+func ParseDate(value string, location *time.Location) (time.Time, error) {
+	if date, err := time.Parse(time.RFC3339, value); err == nil {
+		return date.In(location), nil
+	}
+
+	if date, err := time.ParseInLocation(dateTimeLayout, value, location); err == nil {
+		return date, nil
+	}
+
+	if date, err := time.ParseInLocation(dateLayout, value, location); err == nil {
+		return EndOfDay(date), nil
+	}
+
+	if year, err := time.ParseInLocation(yearLayout, value, location); err == nil {
+		return EndOfDay(year.AddDate(1, 0, -1)), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date: %s. Must be an RFC3339 timestamp, a date (YYYY-MM-DD) or a year (YYYY)", value)
+}
+
+// EndOfDay returns the last second of the day of the given date, inside the date location
+func EndOfDay(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 59, 0, date.Location())
+}
+
+// GetDay returns the day of the given timestamp inside the given location, as midnight UTC of such day
+func GetDay(timestamp time.Time, location *time.Location) time.Time {
+	year, month, day := timestamp.In(location).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// DaysBetween returns the number of days between the given days, including both of them
+func DaysBetween(first, last time.Time) int64 {
+	return int64(last.Sub(first)/(24*time.Hour)) + 1
+}
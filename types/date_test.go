@@ -0,0 +1,70 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	location := time.FixedZone("CET", 60*60)
+
+	testCases := []struct {
+		name      string
+		value     string
+		shouldErr bool
+		expected  time.Time
+	}{
+		{
+			name:     "RFC3339 timestamp is used as it is",
+			value:    "2023-12-31T23:00:00Z",
+			expected: time.Date(2023, time.December, 31, 23, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "timestamp without timezone is read inside the location",
+			value:    "2023-12-31T12:30:00",
+			expected: time.Date(2023, time.December, 31, 12, 30, 0, 0, location),
+		},
+		{
+			name:     "date is resolved to the end of the day inside the location",
+			value:    "2023-06-15",
+			expected: time.Date(2023, time.June, 15, 23, 59, 59, 0, location),
+		},
+		{
+			name:     "year is resolved to the end of the last day of the year inside the location",
+			value:    "2023",
+			expected: time.Date(2023, time.December, 31, 23, 59, 59, 0, location),
+		},
+		{
+			name:      "invalid date returns error",
+			value:     "31/12/2023",
+			shouldErr: true,
+		},
+		{
+			name:      "empty value returns error",
+			value:     "",
+			shouldErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			date, err := ParseDate(tc.value, location)
+			if tc.shouldErr {
+				if err == nil {
+					t.Fatalf("expected error, got %s", date)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !date.Equal(tc.expected) {
+				t.Errorf("expected %s, got %s", tc.expected, date)
+			}
+			if date.Location() != location {
+				t.Errorf("expected location %s, got %s", location, date.Location())
+			}
+		})
+	}
+}
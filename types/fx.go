@@ -0,0 +1,227 @@
+package types
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ExchangeRatesSourceECB identifies the reference rates published by the European Central Bank
+	ExchangeRatesSourceECB = "ECB"
+
+	// ExchangeRatesSourceBancaDItalia identifies the daily rates published by Banca d'Italia
+	ExchangeRatesSourceBancaDItalia = "Banca d'Italia"
+
+	// maxExchangeRateAge is the maximum number of days an exchange rate is used for when no newer one is published
+	// (e.g. during weekends and bank holidays)
+	maxExchangeRateAge = 10
+)
+
+// ExchangeRate represents the official exchange rate between two currencies on a given day,
+// meaning that 1 unit of the base currency is worth Rate units of the quote currency
+type ExchangeRate struct {
+	Base   string    `yaml:"base" json:"base"`
+	Quote  string    `yaml:"quote" json:"quote"`
+	Date   time.Time `yaml:"date" json:"date"`
+	Rate   float64   `yaml:"rate" json:"rate"`
+	Source string    `yaml:"source" json:"source"`
+}
+
+func NewExchangeRate(base string, quote string, date time.Time, rate float64, source string) ExchangeRate {
+	return ExchangeRate{
+		Base:   strings.ToUpper(base),
+		Quote:  strings.ToUpper(quote),
+		Date:   date,
+		Rate:   rate,
+		Source: source,
+	}
+}
+
+// Converts tells whether this rate allows to convert between the given currencies
+func (r ExchangeRate) Converts(from, to string) bool {
+	return strings.EqualFold(r.Base, from) && strings.EqualFold(r.Quote, to) ||
+		strings.EqualFold(r.Base, to) && strings.EqualFold(r.Quote, from)
+}
+
+// Convert converts the given value, expressed in the given currency, into the other currency of this rate
+func (r ExchangeRate) Convert(value float64, from string) (float64, error) {
+	switch {
+	case strings.EqualFold(r.Base, from):
+		return value * r.Rate, nil
+	case strings.EqualFold(r.Quote, from):
+		return value / r.Rate, nil
+	default:
+		return 0, fmt.Errorf("exchange rate %s/%s cannot convert %s", r.Base, r.Quote, from)
+	}
+}
+
+// String implements fmt.Stringer
+func (r ExchangeRate) String() string {
+	return fmt.Sprintf("1 %s = %s %s (%s, %s)",
+		r.Base, strconv.FormatFloat(r.Rate, 'f', -1, 64), r.Quote, r.Source, r.Date.Format(dateLayout))
+}
+
+// --------------------------------------------------------------------------------------------------------------------
+
+// ParseExchangeRates parses the given CSV file contents published by the provided source
+func ParseExchangeRates(bz []byte, source string) ([]ExchangeRate, error) {
+	switch source {
+	case ExchangeRatesSourceECB:
+		return ParseECBExchangeRates(bz)
+	case ExchangeRatesSourceBancaDItalia:
+		return ParseBancaDItaliaExchangeRates(bz)
+	default:
+		return nil, fmt.Errorf("invalid exchange rates source: %s", source)
+	}
+}
+
+// ParseECBExchangeRates parses the euro foreign exchange reference rates CSV published by the ECB
+// (e.g. eurofxref-hist.csv), which contains one row per day and one column per currency
+func ParseECBExchangeRates(bz []byte) ([]ExchangeRate, error) {
+	records, err := readCSV(bz, ',')
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 || !strings.EqualFold(records[0][0], "Date") {
+		return nil, fmt.Errorf("invalid ECB file: missing Date column")
+	}
+
+	header := records[0]
+
+	var rates []ExchangeRate
+	for _, record := range records[1:] {
+		date, err := time.Parse(dateLayout, record[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid ECB date: %s", record[0])
+		}
+
+		for i := 1; i < len(record) && i < len(header); i++ {
+			currency, value := header[i], record[i]
+			if currency == "" || value == "" || value == "N/A" {
+				continue
+			}
+
+			rate, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ECB rate of %s on %s: %s", currency, record[0], value)
+			}
+
+			rates = append(rates, NewExchangeRate("EUR", currency, date, rate, ExchangeRatesSourceECB))
+		}
+	}
+
+	return rates, nil
+}
+
+// ParseBancaDItaliaExchangeRates parses the daily exchange rates CSV published by Banca d'Italia,
+// which contains one row per currency and day. The rates are quoted as units of foreign currency per
+// 1 Euro, or per 1 US Dollar for the currencies whose convention says so
+func ParseBancaDItaliaExchangeRates(bz []byte) ([]ExchangeRate, error) {
+	records, err := readCSV(bz, ',')
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the header, skipping any title row
+	headerIndex := -1
+	for i, record := range records {
+		if findColumn(record, "codice iso") != -1 {
+			headerIndex = i
+			break
+		}
+	}
+
+	if headerIndex == -1 {
+		return nil, fmt.Errorf("invalid Banca d'Italia file: missing Codice ISO column")
+	}
+
+	header := records[headerIndex]
+	currencyColumn := findColumn(header, "codice iso")
+	rateColumn := findColumn(header, "quotazione")
+	conventionColumn := findColumn(header, "convenzione")
+	dateColumn := findColumn(header, "data")
+	if rateColumn == -1 || dateColumn == -1 {
+		return nil, fmt.Errorf("invalid Banca d'Italia file: missing Quotazione or Data columns")
+	}
+
+	var rates []ExchangeRate
+	for _, record := range records[headerIndex+1:] {
+		if len(record) <= max(currencyColumn, rateColumn, dateColumn) {
+			continue
+		}
+
+		value := strings.ReplaceAll(record[rateColumn], ",", ".")
+		if value == "" || value == "N.D." {
+			continue
+		}
+
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Banca d'Italia rate of %s: %s", record[currencyColumn], value)
+		}
+
+		date, err := parseBancaDItaliaDate(record[dateColumn])
+		if err != nil {
+			return nil, err
+		}
+
+		base := "EUR"
+		if conventionColumn != -1 && conventionColumn < len(record) &&
+			strings.Contains(strings.ToLower(record[conventionColumn]), "dollaro") {
+			base = "USD"
+		}
+
+		rates = append(rates, NewExchangeRate(base, record[currencyColumn], date, rate, ExchangeRatesSourceBancaDItalia))
+	}
+
+	return rates, nil
+}
+
+// parseBancaDItaliaDate parses the given reference date, which can be either in the YYYY-MM-DD or DD/MM/YYYY format
+func parseBancaDItaliaDate(value string) (time.Time, error) {
+	if date, err := time.Parse(dateLayout, value); err == nil {
+		return date, nil
+	}
+
+	if date, err := time.Parse("02/01/2006", value); err == nil {
+		return date, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid Banca d'Italia date: %s", value)
+}
+
+// readCSV reads all the records of the given CSV contents, allowing rows with a different number of fields
+func readCSV(bz []byte, separator rune) ([][]string, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(bz, []byte("\xef\xbb\xbf"))))
+	reader.Comma = separator
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error while reading CSV: %w", err)
+	}
+
+	for _, record := range records {
+		for i := range record {
+			record[i] = strings.TrimSpace(record[i])
+		}
+	}
+
+	return records, nil
+}
+
+// findColumn returns the index of the first column whose name starts with the given prefix, or -1 if not found
+func findColumn(header []string, prefix string) int {
+	for i, column := range header {
+		if strings.HasPrefix(strings.ToLower(column), prefix) {
+			return i
+		}
+	}
+	return -1
+}
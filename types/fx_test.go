@@ -0,0 +1,134 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseECBExchangeRates(t *testing.T) {
+	testCases := []struct {
+		name      string
+		data      string
+		shouldErr bool
+		expected  []ExchangeRate
+	}{
+		{
+			name: "valid file is parsed properly",
+			data: "Date,USD,JPY,CYP,\n" +
+				"2023-12-29,1.105,156.33,N/A,\n" +
+				"2023-12-28,1.1114,157.13,,\n",
+			expected: []ExchangeRate{
+				NewExchangeRate("EUR", "USD", time.Date(2023, time.December, 29, 0, 0, 0, 0, time.UTC), 1.105, ExchangeRatesSourceECB),
+				NewExchangeRate("EUR", "JPY", time.Date(2023, time.December, 29, 0, 0, 0, 0, time.UTC), 156.33, ExchangeRatesSourceECB),
+				NewExchangeRate("EUR", "USD", time.Date(2023, time.December, 28, 0, 0, 0, 0, time.UTC), 1.1114, ExchangeRatesSourceECB),
+				NewExchangeRate("EUR", "JPY", time.Date(2023, time.December, 28, 0, 0, 0, 0, time.UTC), 157.13, ExchangeRatesSourceECB),
+			},
+		},
+		{
+			name: "byte order mark is ignored",
+			data: "\xef\xbb\xbfDate,USD\n2023-12-29,1.105\n",
+			expected: []ExchangeRate{
+				NewExchangeRate("EUR", "USD", time.Date(2023, time.December, 29, 0, 0, 0, 0, time.UTC), 1.105, ExchangeRatesSourceECB),
+			},
+		},
+		{
+			name:      "missing Date column returns error",
+			data:      "Day,USD\n2023-12-29,1.105\n",
+			shouldErr: true,
+		},
+		{
+			name:      "invalid date returns error",
+			data:      "Date,USD\n29/12/2023,1.105\n",
+			shouldErr: true,
+		},
+		{
+			name:      "invalid rate returns error",
+			data:      "Date,USD\n2023-12-29,abc\n",
+			shouldErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rates, err := ParseECBExchangeRates([]byte(tc.data))
+			assertExchangeRates(t, tc.shouldErr, tc.expected, rates, err)
+		})
+	}
+}
+
+func TestParseBancaDItaliaExchangeRates(t *testing.T) {
+	testCases := []struct {
+		name      string
+		data      string
+		shouldErr bool
+		expected  []ExchangeRate
+	}{
+		{
+			name: "valid file with title row is parsed properly",
+			data: "Cambi di riferimento giornalieri\n" +
+				"Paese,Valuta,Codice ISO,Codice UIC,Quotazione,Convenzione di cambio,Data di riferimento (CET)\n" +
+				"STATI UNITI,Dollaro USA,USD,001,\"1,105\",Quantita' di valuta estera per 1 Euro,2023-12-29\n" +
+				"GIAPPONE,Yen giapponese,JPY,071,\"156,33\",Quantita' di valuta estera per 1 Euro,29/12/2023\n" +
+				"ARGENTINA,Peso argentino,ARS,216,\"808,45\",Quantita' di valuta estera per 1 Dollaro USA,2023-12-29\n" +
+				"VENEZUELA,Bolivar,VES,207,N.D.,Quantita' di valuta estera per 1 Dollaro USA,2023-12-29\n",
+			expected: []ExchangeRate{
+				NewExchangeRate("EUR", "USD", time.Date(2023, time.December, 29, 0, 0, 0, 0, time.UTC), 1.105, ExchangeRatesSourceBancaDItalia),
+				NewExchangeRate("EUR", "JPY", time.Date(2023, time.December, 29, 0, 0, 0, 0, time.UTC), 156.33, ExchangeRatesSourceBancaDItalia),
+				NewExchangeRate("USD", "ARS", time.Date(2023, time.December, 29, 0, 0, 0, 0, time.UTC), 808.45, ExchangeRatesSourceBancaDItalia),
+			},
+		},
+		{
+			name:      "missing Codice ISO column returns error",
+			data:      "Paese,Valuta,Quotazione,Data di riferimento (CET)\nSTATI UNITI,Dollaro USA,\"1,105\",2023-12-29\n",
+			shouldErr: true,
+		},
+		{
+			name:      "missing Quotazione column returns error",
+			data:      "Codice ISO,Data di riferimento (CET)\nUSD,2023-12-29\n",
+			shouldErr: true,
+		},
+		{
+			name:      "invalid rate returns error",
+			data:      "Codice ISO,Quotazione,Data di riferimento (CET)\nUSD,abc,2023-12-29\n",
+			shouldErr: true,
+		},
+		{
+			name:      "invalid date returns error",
+			data:      "Codice ISO,Quotazione,Data di riferimento (CET)\nUSD,\"1,105\",29-12-2023\n",
+			shouldErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rates, err := ParseBancaDItaliaExchangeRates([]byte(tc.data))
+			assertExchangeRates(t, tc.shouldErr, tc.expected, rates, err)
+		})
+	}
+}
+
+// assertExchangeRates checks that the given parsing result matches the expected one
+func assertExchangeRates(t *testing.T, shouldErr bool, expected []ExchangeRate, rates []ExchangeRate, err error) {
+	t.Helper()
+
+	if shouldErr {
+		if err == nil {
+			t.Fatalf("expected error, got %v", rates)
+		}
+		return
+	}
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(rates) != len(expected) {
+		t.Fatalf("expected %d rates, got %d: %v", len(expected), len(rates), rates)
+	}
+
+	for i := range expected {
+		if rates[i] != expected[i] {
+			t.Errorf("rate %d: expected %s, got %s", i, expected[i], rates[i])
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GroupBy represents the way in which the amounts of a report are grouped together
+type GroupBy byte
+
+func (g GroupBy) String() string {
+	switch g {
+	case GroupByAsset:
+		return "asset"
+
+	case GroupByChain:
+		return "chain"
+
+	case GroupByAddress:
+		return "address"
+
+	default:
+		panic(fmt.Errorf("invalid group by value: %d", g))
+	}
+}
+
+const (
+	GroupByAsset   GroupBy = 1
+	GroupByChain   GroupBy = 2
+	GroupByAddress GroupBy = 3
+)
+
+func ParseGroupBy(value string) (GroupBy, error) {
+	switch strings.ToLower(value) {
+	case "asset":
+		return GroupByAsset, nil
+	case "chain":
+		return GroupByChain, nil
+	case "address":
+		return GroupByAddress, nil
+	default:
+		return 0, fmt.Errorf("invalid group by value: %s", value)
+	}
+}
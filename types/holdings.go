@@ -0,0 +1,72 @@
+package types
+
+import (
+	"sort"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Holdings contains the coins held by an address, split by the category they belong to
+type Holdings map[Category]sdk.Coins
+
+func NewHoldings() Holdings {
+	return Holdings{}
+}
+
+// Add adds the given coins to the ones of the provided category
+func (h Holdings) Add(category Category, coins ...sdk.Coin) {
+	if len(coins) == 0 {
+		return
+	}
+
+	existing, ok := h[category]
+	if !ok {
+		existing = sdk.NewCoins()
+	}
+	h[category] = existing.Add(coins...)
+}
+
+// Merge adds all the coins contained inside the given holdings to these ones
+func (h Holdings) Merge(other Holdings) {
+	for category, coins := range other {
+		h.Add(category, coins...)
+	}
+}
+
+// GetCategories returns the sorted list of categories that are contained inside the holdings
+func (h Holdings) GetCategories() []Category {
+	categories := make([]Category, 0, len(h))
+	for category := range h {
+		categories = append(categories, category)
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		return categories[i] < categories[j]
+	})
+
+	return categories
+}
+
+// SubClamped subtracts the given coins from the provided ones, clamping each resulting amount to zero.
+// The returned boolean tells whether any amount has been clamped.
+func SubClamped(coins sdk.Coins, subtrahend sdk.Coins) (sdk.Coins, bool) {
+	result := sdk.NewCoins()
+	clamped := false
+	for _, coin := range coins {
+		amount := coin.Amount.Sub(subtrahend.AmountOf(coin.Denom))
+		if amount.IsNegative() {
+			clamped = true
+			continue
+		}
+		result = result.Add(sdk.NewCoin(coin.Denom, amount))
+	}
+
+	// Subtracting a denom which is not part of the coins always results in a negative amount
+	for _, coin := range subtrahend {
+		if coin.Amount.IsPositive() && coins.AmountOf(coin.Denom).IsZero() {
+			clamped = true
+		}
+	}
+
+	return result, clamped
+}
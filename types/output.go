@@ -18,6 +18,12 @@ func (o Output) String() string {
 	case OutCSV:
 		return "csv"
 
+	case OutRW:
+		return "rw"
+
+	case OutRWCSV:
+		return "rw-csv"
+
 	default:
 		panic(fmt.Errorf("invalid output type: %d", o))
 	}
@@ -27,8 +33,18 @@ const (
 	OutText Output = 1
 	OutJSON Output = 2
 	OutCSV  Output = 3
+
+	// OutRW and OutRWCSV output the Quadro RW of the year of the report date,
+	// respectively as a printable layout and as CSV
+	OutRW    Output = 4
+	OutRWCSV Output = 5
 )
 
+// IsRW tells whether the output is one of the Quadro RW ones
+func (o Output) IsRW() bool {
+	return o == OutRW || o == OutRWCSV
+}
+
 func ParseOutput(out string) (Output, error) {
 	switch strings.ToLower(out) {
 	case "csv":
@@ -37,6 +53,10 @@ func ParseOutput(out string) (Output, error) {
 		return OutJSON, nil
 	case "text":
 		return OutText, nil
+	case "rw":
+		return OutRW, nil
+	case "rw-csv":
+		return OutRWCSV, nil
 	default:
 		return 0, fmt.Errorf("invalid output type: %s", out)
 	}
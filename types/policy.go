@@ -0,0 +1,30 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BlockPolicy represents the way in which the block used for a report is chosen among the ones
+// that are closest to the requested timestamp
+type BlockPolicy string
+
+const (
+	// BlockPolicyLastBefore selects the last block having a timestamp equal or before the requested one
+	BlockPolicyLastBefore BlockPolicy = "last-before"
+
+	// BlockPolicyFirstAfter selects the first block having a timestamp equal or after the requested one
+	BlockPolicyFirstAfter BlockPolicy = "first-after"
+
+	// BlockPolicyNearest selects the block having the timestamp closest to the requested one
+	BlockPolicyNearest BlockPolicy = "nearest"
+)
+
+func ParseBlockPolicy(value string) (BlockPolicy, error) {
+	switch policy := BlockPolicy(strings.ToLower(value)); policy {
+	case BlockPolicyLastBefore, BlockPolicyFirstAfter, BlockPolicyNearest:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid block policy value: %s", value)
+	}
+}
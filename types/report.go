@@ -2,6 +2,10 @@ package types
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/hashicorp/go-uuid"
@@ -25,8 +29,12 @@ func (r ReportID) String() string {
 // --------------------------------------------------------------------------------------------------------------------
 
 type ReportResult struct {
-	Error   string         `json:"error"`
-	Amounts []AmountOutput `json:"amounts"`
+	Error    string          `json:"error"`
+	Amounts  []*Amount       `json:"amounts"`
+	Metadata *ReportMetadata `json:"metadata,omitempty"`
+
+	// Tax contains the tax due on the amounts, if it has been requested
+	Tax *TaxReport `json:"tax,omitempty"`
 }
 
 func NewErrorReportResult(err error) *ReportResult {
@@ -35,9 +43,10 @@ func NewErrorReportResult(err error) *ReportResult {
 	}
 }
 
-func NewAmountsReportResult(amounts []AmountOutput) *ReportResult {
+func NewAmountsReportResult(amounts []*Amount, metadata *ReportMetadata) *ReportResult {
 	return &ReportResult{
-		Amounts: amounts,
+		Amounts:  amounts,
+		Metadata: metadata,
 	}
 }
 
@@ -49,33 +58,210 @@ func (r ReportResult) Err() error {
 	return fmt.Errorf(r.Error)
 }
 
-func (r ReportResult) GetAmounts() []AmountOutput {
-	return r.Amounts
+// GetAmounts returns the amounts contained inside the result, grouped based on the given value
+func (r ReportResult) GetAmounts(groupBy GroupBy) []AmountOutput {
+	return Format(GroupAmounts(r.Amounts, groupBy))
+}
+
+// --------------------------------------------------------------------------------------------------------------------
+
+// ReportMetadata contains the details about how the amounts of a report have been computed
+type ReportMetadata struct {
+	Date        time.Time      `yaml:"date" json:"date"`
+	BlockPolicy BlockPolicy    `yaml:"blockPolicy" json:"blockPolicy"`
+	MaxBlockGap string         `yaml:"maxBlockGap" json:"maxBlockGap"`
+	Blocks      []*ReportBlock `yaml:"blocks" json:"blocks"`
+	Warnings    []string       `yaml:"warnings" json:"warnings"`
+
+	// ExchangeRates contains the rates that have been used to convert the values into the report currency
+	ExchangeRates []ExchangeRate `yaml:"exchangeRates,omitempty" json:"exchangeRates,omitempty"`
+
+	// FailedChains contains the names of the chains whose amounts could not be read, and are missing from the report
+	FailedChains []string `yaml:"failedChains,omitempty" json:"failedChains,omitempty"`
+}
+
+func NewReportMetadata(date time.Time, policy BlockPolicy, maxBlockGap time.Duration) *ReportMetadata {
+	return &ReportMetadata{
+		Date:        date,
+		BlockPolicy: policy,
+		MaxBlockGap: maxBlockGap.String(),
+	}
+}
+
+// AddWarning adds a new warning to the metadata
+func (m *ReportMetadata) AddWarning(format string, args ...interface{}) {
+	m.Warnings = append(m.Warnings, fmt.Sprintf(format, args...))
+}
+
+// AddFailedChain marks the given chain as failed because of the provided error, adding a warning about it
+func (m *ReportMetadata) AddFailedChain(chainName string, err error) {
+	m.FailedChains = append(m.FailedChains, chainName)
+	m.AddWarning("%s amounts are missing since they could not be read: %s", chainName, err)
+}
+
+// ValidateComplete returns an error if the amounts of any chain are missing from the report
+func (m *ReportMetadata) ValidateComplete() error {
+	if len(m.FailedChains) > 0 {
+		return fmt.Errorf("the amounts of the following chains could not be read: %s", strings.Join(m.FailedChains, ", "))
+	}
+	return nil
+}
+
+// AddExchangeRate adds the given rate to the ones used by the report, if not present already
+func (m *ReportMetadata) AddExchangeRate(rate ExchangeRate) {
+	for _, existing := range m.ExchangeRates {
+		if existing.Base == rate.Base && existing.Quote == rate.Quote && existing.Source == rate.Source &&
+			existing.Date.Equal(rate.Date) {
+			return
+		}
+	}
+	m.ExchangeRates = append(m.ExchangeRates, rate)
+}
+
+// ReportBlock contains the details of the block that has been used to compute the amounts of a chain
+type ReportBlock struct {
+	ChainName string    `yaml:"chain" json:"chain"`
+	ChainID   string    `yaml:"chainId,omitempty" json:"chainId,omitempty"`
+	Height    int64     `yaml:"height" json:"height"`
+	Timestamp time.Time `yaml:"timestamp" json:"timestamp"`
+
+	// Gap is the distance between the block time and the requested date
+	Gap string `yaml:"gap" json:"gap"`
+
+	// Endpoints contains the addresses of the RPC endpoints that served the data of the chain
+	Endpoints []string `yaml:"endpoints" json:"endpoints"`
+}
+
+func NewReportBlock(
+	chainName string, chainID string, height int64, timestamp time.Time, gap time.Duration, endpoints []string,
+) *ReportBlock {
+	return &ReportBlock{
+		ChainName: chainName,
+		ChainID:   chainID,
+		Height:    height,
+		Timestamp: timestamp,
+		Gap:       gap.String(),
+		Endpoints: endpoints,
+	}
 }
 
 // --------------------------------------------------------------------------------------------------------------------
 
+// Category represents the kind of holding that an amount refers to
+type Category string
+
+const (
+	// CategoryBank identifies the liquid tokens held inside the bank module
+	CategoryBank Category = "bank"
+
+	// CategoryCW20 identifies the liquid tokens held inside CW20 contracts
+	CategoryCW20 Category = "cw20"
+
+	// CategoryDelegated identifies the tokens that are currently delegated to a validator
+	CategoryDelegated Category = "delegated"
+
+	// CategoryRedelegating identifies the tokens that are being redelegated from a validator to another one
+	CategoryRedelegating Category = "redelegating"
+
+	// CategoryUnbonding identifies the tokens that are being unbonded from a validator
+	CategoryUnbonding Category = "unbonding"
+
+	// CategoryRewards identifies the staking rewards that have been accrued but not withdrawn yet
+	CategoryRewards Category = "rewards"
+
+	// CategoryOsmosisLocked identifies the tokens that are locked inside Osmosis pools
+	CategoryOsmosisLocked Category = "osmosis-locked"
+
+	// CategoryOsmosisUnlocking identifies the tokens that are being unlocked from Osmosis pools
+	CategoryOsmosisUnlocking Category = "osmosis-unlocking"
+
+	// CategoryOsmosisLP identifies the tokens provided as liquidity to Osmosis concentrated-liquidity pools
+	CategoryOsmosisLP Category = "osmosis-lp"
+)
+
+// Origin contains the details about where an amount has been read from
+type Origin struct {
+	ChainName string    `yaml:"chain" json:"chain"`
+	Address   string    `yaml:"address" json:"address"`
+	Height    int64     `yaml:"height" json:"height"`
+	Timestamp time.Time `yaml:"timestamp" json:"timestamp"`
+}
+
+func NewOrigin(chainName string, address string, height int64, timestamp time.Time) Origin {
+	return Origin{
+		ChainName: chainName,
+		Address:   address,
+		Height:    height,
+		Timestamp: timestamp,
+	}
+}
+
 type Amount struct {
-	Asset  *Asset  `yaml:"asset" json:"asset"`
-	Amount sdk.Dec `yaml:"amount" json:"amount"`
-	Value  sdk.Dec `yaml:"value" json:"value"`
+	Asset    *Asset   `yaml:"asset" json:"asset"`
+	Origin   Origin   `yaml:"origin" json:"origin"`
+	Category Category `yaml:"category" json:"category"`
+	Amount   sdk.Dec  `yaml:"amount" json:"amount"`
+	Value    sdk.Dec  `yaml:"value" json:"value"`
+
+	// ManualPrice tells whether the value has been computed using a manual price, described by PriceSource
+	ManualPrice bool   `yaml:"manualPrice,omitempty" json:"manualPrice,omitempty"`
+	PriceSource string `yaml:"priceSource,omitempty" json:"priceSource,omitempty"`
+
+	// ExchangeRate is the rate used to convert the value into the report currency, if any
+	ExchangeRate *ExchangeRate `yaml:"exchangeRate,omitempty" json:"exchangeRate,omitempty"`
+
+	// MissingPrice tells whether the value is zero because the price of the asset was not found
+	MissingPrice bool `yaml:"missingPrice,omitempty" json:"missingPrice,omitempty"`
 }
 
-func NewAmount(asset *Asset, amount sdk.Dec, value sdk.Dec) *Amount {
+func NewAmount(asset *Asset, origin Origin, category Category, amount sdk.Dec, value sdk.Dec) *Amount {
 	return &Amount{
-		Asset:  asset,
-		Amount: amount,
-		Value:  value,
+		Asset:    asset,
+		Origin:   origin,
+		Category: category,
+		Amount:   amount,
+		Value:    value,
 	}
 }
 
+// WithManualPrice marks the amount as valued using a manual price having the given source
+func (a *Amount) WithManualPrice(source string) *Amount {
+	a.ManualPrice = true
+	a.PriceSource = source
+	return a
+}
+
+// WithExchangeRate sets the rate that has been used to convert the value of the amount
+func (a *Amount) WithExchangeRate(rate *ExchangeRate) *Amount {
+	a.ExchangeRate = rate
+	return a
+}
+
+// WithMissingPrice marks the amount as valued at zero since the price of its asset was not found
+func (a *Amount) WithMissingPrice() *Amount {
+	a.MissingPrice = true
+	return a
+}
+
 // --------------------------------------------------------------------------------------------------------------------
 // CSV Support
 
 type AmountOutput struct {
-	Asset  string `json:"asset" yaml:"asset" csv:"asset"`
-	Amount string `json:"amount" yaml:"amount" csv:"amount"`
-	Value  string `json:"value" yaml:"value" csv:"value"`
+	Asset    string `json:"asset" yaml:"asset" csv:"asset"`
+	Category string `json:"category" yaml:"category" csv:"category"`
+	Amount   string `json:"amount" yaml:"amount" csv:"amount"`
+	Value    string `json:"value" yaml:"value" csv:"value"`
+	Chain    string `json:"chain,omitempty" yaml:"chain,omitempty" csv:"chain"`
+	Address  string `json:"address,omitempty" yaml:"address,omitempty" csv:"address"`
+	Height   string `json:"height,omitempty" yaml:"height,omitempty" csv:"height"`
+	Time     string `json:"time,omitempty" yaml:"time,omitempty" csv:"time"`
+
+	ManualPrice  string `json:"manual_price,omitempty" yaml:"manual_price,omitempty" csv:"manual_price"`
+	MissingPrice string `json:"missing_price,omitempty" yaml:"missing_price,omitempty" csv:"missing_price"`
+	PriceSource  string `json:"price_source,omitempty" yaml:"price_source,omitempty" csv:"price_source"`
+
+	ExchangeRate       string `json:"exchange_rate,omitempty" yaml:"exchange_rate,omitempty" csv:"exchange_rate"`
+	ExchangeRateSource string `json:"exchange_rate_source,omitempty" yaml:"exchange_rate_source,omitempty" csv:"exchange_rate_source"`
 }
 
 // Format formats the given amounts to be later printed properly
@@ -83,48 +269,166 @@ func Format(amounts []*Amount) []AmountOutput {
 	csvAmounts := make([]AmountOutput, len(amounts))
 	for i, amount := range amounts {
 		csvAmounts[i] = AmountOutput{
-			Asset:  amount.Asset.Symbol,
-			Amount: amount.Amount.String(),
-			Value:  amount.Value.String(),
+			Asset:    amount.Asset.Symbol,
+			Category: string(amount.Category),
+			Amount:   amount.Amount.String(),
+			Value:    amount.Value.String(),
+			Chain:    amount.Origin.ChainName,
+			Address:  amount.Origin.Address,
+		}
+
+		if amount.ManualPrice {
+			csvAmounts[i].ManualPrice = strconv.FormatBool(amount.ManualPrice)
+			csvAmounts[i].PriceSource = amount.PriceSource
+		}
+
+		if amount.MissingPrice {
+			csvAmounts[i].MissingPrice = strconv.FormatBool(amount.MissingPrice)
+		}
+
+		if amount.ExchangeRate != nil {
+			csvAmounts[i].ExchangeRate = amount.ExchangeRate.String()
+			csvAmounts[i].ExchangeRateSource = amount.ExchangeRate.Source
+		}
+
+		if amount.Origin.Height != 0 {
+			csvAmounts[i].Height = strconv.FormatInt(amount.Origin.Height, 10)
+			csvAmounts[i].Time = amount.Origin.Timestamp.Format(time.RFC3339)
 		}
 	}
 	return csvAmounts
 }
 
+type MetadataOutput struct {
+	Field string `json:"field" yaml:"field" csv:"field"`
+	Value string `json:"value" yaml:"value" csv:"value"`
+}
+
+// FormatMetadata formats the given metadata to be later printed properly, using one row for each field.
+// Fields having multiple values, such as the warnings, are repeated once for each value.
+func FormatMetadata(metadata *ReportMetadata) []MetadataOutput {
+	outputs := []MetadataOutput{
+		{Field: "date", Value: metadata.Date.Format(time.RFC3339)},
+		{Field: "block_policy", Value: string(metadata.BlockPolicy)},
+		{Field: "max_block_gap", Value: metadata.MaxBlockGap},
+	}
+	for _, rate := range metadata.ExchangeRates {
+		outputs = append(outputs, MetadataOutput{Field: "exchange_rate", Value: rate.String()})
+	}
+	for _, chainName := range metadata.FailedChains {
+		outputs = append(outputs, MetadataOutput{Field: "failed_chain", Value: chainName})
+	}
+	for _, warning := range metadata.Warnings {
+		outputs = append(outputs, MetadataOutput{Field: "warning", Value: warning})
+	}
+	return outputs
+}
+
+type ReportBlockOutput struct {
+	Chain     string `json:"chain" yaml:"chain" csv:"chain"`
+	ChainID   string `json:"chain_id" yaml:"chain_id" csv:"chain_id"`
+	Height    string `json:"height" yaml:"height" csv:"height"`
+	Time      string `json:"time" yaml:"time" csv:"time"`
+	Gap       string `json:"gap" yaml:"gap" csv:"gap"`
+	Endpoints string `json:"endpoints" yaml:"endpoints" csv:"endpoints"`
+}
+
+// FormatBlocks formats the blocks of the given metadata to be later printed properly.
+// The endpoints that served the data of each chain are separated by spaces.
+func FormatBlocks(metadata *ReportMetadata) []ReportBlockOutput {
+	outputs := make([]ReportBlockOutput, len(metadata.Blocks))
+	for i, block := range metadata.Blocks {
+		outputs[i] = ReportBlockOutput{
+			Chain:     block.ChainName,
+			ChainID:   block.ChainID,
+			Height:    strconv.FormatInt(block.Height, 10),
+			Time:      block.Timestamp.Format(time.RFC3339),
+			Gap:       block.Gap,
+			Endpoints: strings.Join(block.Endpoints, " "),
+		}
+	}
+	return outputs
+}
+
 // --------------------------------------------------------------------------------------------------------------------
 
-// MergeSameAssetsAmounts merges together the various amounts for the same assets present inside the given slice
+// GroupAmounts groups together the given amounts based on the provided value.
+// The returned amounts are sorted by chain, address, asset and category.
+func GroupAmounts(amounts []*Amount, groupBy GroupBy) []*Amount {
+	var grouped []*Amount
+	switch groupBy {
+	case GroupByChain:
+		grouped = mergeAmounts(amounts, func(origin Origin) Origin {
+			return NewOrigin(origin.ChainName, "", origin.Height, origin.Timestamp)
+		})
+	case GroupByAddress:
+		grouped = mergeAmounts(amounts, func(origin Origin) Origin {
+			return origin
+		})
+	default:
+		grouped = MergeSameAssetsAmounts(amounts)
+	}
+
+	sort.SliceStable(grouped, func(i, j int) bool {
+		first, second := grouped[i], grouped[j]
+		if first.Origin.ChainName != second.Origin.ChainName {
+			return first.Origin.ChainName < second.Origin.ChainName
+		}
+		if first.Origin.Address != second.Origin.Address {
+			return first.Origin.Address < second.Origin.Address
+		}
+		if first.Asset.Symbol != second.Asset.Symbol {
+			return first.Asset.Symbol < second.Asset.Symbol
+		}
+		return first.Category < second.Category
+	})
+
+	return grouped
+}
+
+// MergeSameAssetsAmounts merges together the various amounts for the same assets present inside the given slice.
+// Amounts having different categories are kept separate so that they can be shown individually.
 func MergeSameAssetsAmounts(slice []*Amount) []*Amount {
-	assets := map[string]*Asset{}
-	amounts := map[string]sdk.Dec{}
-	values := map[string]sdk.Dec{}
+	return mergeAmounts(slice, func(Origin) Origin {
+		return Origin{}
+	})
+}
 
-	// Collect all the unique assets
-	for _, amount := range slice {
+// mergeAmounts merges together the amounts of the same asset and category that have the same origin
+// once mapped using the given function
+func mergeAmounts(slice []*Amount, mapOrigin func(origin Origin) Origin) []*Amount {
+	var keys []string
+	merged := map[string]*Amount{}
 
-		// Store the asset
-		if _, ok := assets[amount.Asset.Name]; !ok {
-			assets[amount.Asset.Name] = amount.Asset
-		}
+	for _, amount := range slice {
+		origin := mapOrigin(amount.Origin)
+		key := fmt.Sprintf("%s/%s/%s/%s", origin.ChainName, origin.Address, amount.Asset.Name, amount.Category)
 
-		// Store the amounts
-		assetAmount, ok := amounts[amount.Asset.Name]
+		mergedAmount, ok := merged[key]
 		if !ok {
-			assetAmount = sdk.ZeroDec()
+			keys = append(keys, key)
+			merged[key] = NewAmount(amount.Asset, origin, amount.Category, amount.Amount, amount.Value)
+			if amount.ManualPrice {
+				merged[key].WithManualPrice(amount.PriceSource)
+			}
+			merged[key].WithExchangeRate(amount.ExchangeRate)
+			merged[key].MissingPrice = amount.MissingPrice
+			continue
 		}
-		amounts[amount.Asset.Name] = assetAmount.Add(amount.Amount)
 
-		// Store the values
-		assetValue, ok := values[amount.Asset.Name]
-		if !ok {
-			assetValue = sdk.ZeroDec()
+		mergedAmount.Amount = mergedAmount.Amount.Add(amount.Amount)
+		mergedAmount.Value = mergedAmount.Value.Add(amount.Value)
+		if amount.ManualPrice && !mergedAmount.ManualPrice {
+			mergedAmount.WithManualPrice(amount.PriceSource)
+		}
+		if amount.MissingPrice {
+			mergedAmount.WithMissingPrice()
 		}
-		values[amount.Asset.Name] = assetValue.Add(amount.Value)
 	}
 
-	var result []*Amount
-	for name, asset := range assets {
-		result = append(result, NewAmount(asset, amounts[name], values[name]))
+	result := make([]*Amount, len(keys))
+	for i, key := range keys {
+		result[i] = merged[key]
 	}
 
 	return result
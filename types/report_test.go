@@ -0,0 +1,172 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestGroupAmounts(t *testing.T) {
+	atom := &Asset{Name: "Cosmos Hub", Symbol: "ATOM"}
+	osmo := &Asset{Name: "Osmosis", Symbol: "OSMO"}
+	timestamp := time.Date(2023, time.December, 31, 23, 59, 59, 0, time.UTC)
+
+	amounts := []*Amount{
+		NewAmount(osmo, NewOrigin("osmosis", "osmo1b", 10, timestamp), CategoryBank, sdk.NewDec(1), sdk.NewDec(2)),
+		NewAmount(atom, NewOrigin("cosmos", "cosmos1a", 20, timestamp), CategoryDelegated, sdk.NewDec(3), sdk.NewDec(30)),
+		NewAmount(atom, NewOrigin("cosmos", "cosmos1b", 20, timestamp), CategoryDelegated, sdk.NewDec(4), sdk.NewDec(40)),
+		NewAmount(atom, NewOrigin("osmosis", "osmo1a", 10, timestamp), CategoryBank, sdk.NewDec(5), sdk.NewDec(50)),
+		NewAmount(atom, NewOrigin("cosmos", "cosmos1a", 20, timestamp), CategoryBank, sdk.NewDec(6), sdk.NewDec(60)),
+		NewAmount(osmo, NewOrigin("osmosis", "osmo1a", 10, timestamp), CategoryBank, sdk.NewDec(7), sdk.NewDec(14)),
+	}
+
+	type expectedAmount struct {
+		chain    string
+		address  string
+		symbol   string
+		category Category
+		amount   int64
+		value    int64
+	}
+
+	testCases := []struct {
+		name     string
+		groupBy  GroupBy
+		expected []expectedAmount
+	}{
+		{
+			name:    "group by asset merges all the origins",
+			groupBy: GroupByAsset,
+			expected: []expectedAmount{
+				{"", "", "ATOM", CategoryBank, 11, 110},
+				{"", "", "ATOM", CategoryDelegated, 7, 70},
+				{"", "", "OSMO", CategoryBank, 8, 16},
+			},
+		},
+		{
+			name:    "group by chain merges the addresses of the same chain",
+			groupBy: GroupByChain,
+			expected: []expectedAmount{
+				{"cosmos", "", "ATOM", CategoryBank, 6, 60},
+				{"cosmos", "", "ATOM", CategoryDelegated, 7, 70},
+				{"osmosis", "", "ATOM", CategoryBank, 5, 50},
+				{"osmosis", "", "OSMO", CategoryBank, 8, 16},
+			},
+		},
+		{
+			name:    "group by address keeps the addresses separate",
+			groupBy: GroupByAddress,
+			expected: []expectedAmount{
+				{"cosmos", "cosmos1a", "ATOM", CategoryBank, 6, 60},
+				{"cosmos", "cosmos1a", "ATOM", CategoryDelegated, 3, 30},
+				{"cosmos", "cosmos1b", "ATOM", CategoryDelegated, 4, 40},
+				{"osmosis", "osmo1a", "ATOM", CategoryBank, 5, 50},
+				{"osmosis", "osmo1a", "OSMO", CategoryBank, 7, 14},
+				{"osmosis", "osmo1b", "OSMO", CategoryBank, 1, 2},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			grouped := GroupAmounts(amounts, tc.groupBy)
+			if len(grouped) != len(tc.expected) {
+				t.Fatalf("expected %d amounts, got %d", len(tc.expected), len(grouped))
+			}
+
+			for i, expected := range tc.expected {
+				amount := grouped[i]
+				if amount.Origin.ChainName != expected.chain || amount.Origin.Address != expected.address ||
+					amount.Asset.Symbol != expected.symbol || amount.Category != expected.category {
+					t.Errorf("amount %d: expected %s/%s/%s/%s, got %s/%s/%s/%s", i,
+						expected.chain, expected.address, expected.symbol, expected.category,
+						amount.Origin.ChainName, amount.Origin.Address, amount.Asset.Symbol, amount.Category)
+				}
+				if !amount.Amount.Equal(sdk.NewDec(expected.amount)) {
+					t.Errorf("amount %d: expected amount %d, got %s", i, expected.amount, amount.Amount)
+				}
+				if !amount.Value.Equal(sdk.NewDec(expected.value)) {
+					t.Errorf("amount %d: expected value %d, got %s", i, expected.value, amount.Value)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeAmounts(t *testing.T) {
+	atom := &Asset{Name: "Cosmos Hub", Symbol: "ATOM"}
+	origin := NewOrigin("cosmos", "cosmos1a", 20, time.Date(2023, time.December, 31, 0, 0, 0, 0, time.UTC))
+	rate := &ExchangeRate{Base: "EUR", Quote: "USD", Rate: 1.1}
+
+	testCases := []struct {
+		name           string
+		amounts        []*Amount
+		expectedLen    int
+		expectedAmount int64
+		expectedValue  int64
+		expectedManual bool
+		expectedSource string
+		expectedRate   *ExchangeRate
+	}{
+		{
+			name: "same asset and category are merged",
+			amounts: []*Amount{
+				NewAmount(atom, origin, CategoryBank, sdk.NewDec(1), sdk.NewDec(10)),
+				NewAmount(atom, origin, CategoryBank, sdk.NewDec(2), sdk.NewDec(20)),
+			},
+			expectedLen:    1,
+			expectedAmount: 3,
+			expectedValue:  30,
+		},
+		{
+			name: "different categories are kept separate",
+			amounts: []*Amount{
+				NewAmount(atom, origin, CategoryBank, sdk.NewDec(1), sdk.NewDec(10)),
+				NewAmount(atom, origin, CategoryRewards, sdk.NewDec(2), sdk.NewDec(20)),
+			},
+			expectedLen:    2,
+			expectedAmount: 1,
+			expectedValue:  10,
+		},
+		{
+			name: "manual price is kept when any merged amount has it",
+			amounts: []*Amount{
+				NewAmount(atom, origin, CategoryBank, sdk.NewDec(1), sdk.NewDec(10)).WithExchangeRate(rate),
+				NewAmount(atom, origin, CategoryBank, sdk.NewDec(2), sdk.NewDec(20)).WithManualPrice("OTC"),
+			},
+			expectedLen:    1,
+			expectedAmount: 3,
+			expectedValue:  30,
+			expectedManual: true,
+			expectedSource: "OTC",
+			expectedRate:   rate,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			merged := mergeAmounts(tc.amounts, func(origin Origin) Origin {
+				return origin
+			})
+			if len(merged) != tc.expectedLen {
+				t.Fatalf("expected %d amounts, got %d", tc.expectedLen, len(merged))
+			}
+
+			amount := merged[0]
+			if !amount.Amount.Equal(sdk.NewDec(tc.expectedAmount)) {
+				t.Errorf("expected amount %d, got %s", tc.expectedAmount, amount.Amount)
+			}
+			if !amount.Value.Equal(sdk.NewDec(tc.expectedValue)) {
+				t.Errorf("expected value %d, got %s", tc.expectedValue, amount.Value)
+			}
+			if amount.ManualPrice != tc.expectedManual || amount.PriceSource != tc.expectedSource {
+				t.Errorf("expected manual price %t (%s), got %t (%s)",
+					tc.expectedManual, tc.expectedSource, amount.ManualPrice, amount.PriceSource)
+			}
+			if amount.ExchangeRate != tc.expectedRate {
+				t.Errorf("expected exchange rate %v, got %v", tc.expectedRate, amount.ExchangeRate)
+			}
+		})
+	}
+}
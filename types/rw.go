@@ -0,0 +1,97 @@
+package types
+
+import (
+	"math"
+	"strconv"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const (
+	// RWCryptoAssetCode is the code identifying crypto-assets inside the Quadro RW
+	RWCryptoAssetCode = "21"
+)
+
+// RWReport contains the rows of the Quadro RW (foreign investments and crypto-assets) for a tax period
+type RWReport struct {
+	Year     int      `yaml:"year" json:"year"`
+	Rows     []*RWRow `yaml:"rows" json:"rows"`
+	Warnings []string `yaml:"warnings" json:"warnings"`
+}
+
+func NewRWReport(year int, rows []*RWRow, warnings []string) *RWReport {
+	return &RWReport{
+		Year:     year,
+		Rows:     rows,
+		Warnings: warnings,
+	}
+}
+
+// RWRow contains the data of a single asset inside the Quadro RW
+type RWRow struct {
+	Asset string `yaml:"asset" json:"asset"`
+
+	// Code identifies the type of the asset
+	Code string `yaml:"code" json:"code"`
+
+	// OwnershipPercentage is the percentage of the asset that is owned, between 0 and 100
+	OwnershipPercentage sdk.Dec `yaml:"ownershipPercentage" json:"ownershipPercentage"`
+
+	// DaysHeld is the number of days the asset has been held during the tax period
+	DaysHeld int64 `yaml:"daysHeld" json:"daysHeld"`
+
+	// InitialValue and FinalValue are the values at the start and end of the tax period
+	InitialValue sdk.Dec `yaml:"initialValue" json:"initialValue"`
+	FinalValue   sdk.Dec `yaml:"finalValue" json:"finalValue"`
+}
+
+func NewRWRow(asset string, code string, ownershipPercentage sdk.Dec, daysHeld int64) *RWRow {
+	return &RWRow{
+		Asset:               asset,
+		Code:                code,
+		OwnershipPercentage: ownershipPercentage,
+		DaysHeld:            daysHeld,
+		InitialValue:        sdk.ZeroDec(),
+		FinalValue:          sdk.ZeroDec(),
+	}
+}
+
+// RoundToUnit rounds the given value to the unit following the Italian conventions used in tax declarations,
+// which round up decimals greater than or equal to 50 cents and round down the others
+func RoundToUnit(value sdk.Dec) sdk.Int {
+	half := sdk.NewDecWithPrec(5, 1)
+	if value.IsNegative() {
+		return value.Sub(half).TruncateInt()
+	}
+	return value.Add(half).TruncateInt()
+}
+
+// --------------------------------------------------------------------------------------------------------------------
+// CSV Support
+
+type RWRowOutput struct {
+	Asset               string `json:"asset" yaml:"asset" csv:"asset"`
+	Code                string `json:"code" yaml:"code" csv:"code"`
+	OwnershipPercentage string `json:"ownership_percentage" yaml:"ownership_percentage" csv:"ownership_percentage"`
+	DaysHeld            string `json:"days_held" yaml:"days_held" csv:"days_held"`
+	InitialValue        string `json:"initial_value" yaml:"initial_value" csv:"initial_value"`
+	FinalValue          string `json:"final_value" yaml:"final_value" csv:"final_value"`
+}
+
+// FormatRW formats the rows of the given report to be later printed properly, rounding the values to the unit
+// and the ownership percentages to two decimals
+func FormatRW(report *RWReport) []RWRowOutput {
+	outputs := make([]RWRowOutput, len(report.Rows))
+	for i, row := range report.Rows {
+		percentage, _ := row.OwnershipPercentage.Float64()
+		outputs[i] = RWRowOutput{
+			Asset:               row.Asset,
+			Code:                row.Code,
+			OwnershipPercentage: strconv.FormatFloat(math.Round(percentage*100)/100, 'f', -1, 64),
+			DaysHeld:            strconv.FormatInt(row.DaysHeld, 10),
+			InitialValue:        RoundToUnit(row.InitialValue).String(),
+			FinalValue:          RoundToUnit(row.FinalValue).String(),
+		}
+	}
+	return outputs
+}
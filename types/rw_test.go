@@ -0,0 +1,37 @@
+package types
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestRoundToUnit(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    string
+		expected int64
+	}{
+		{name: "integer value is kept", value: "100", expected: 100},
+		{name: "decimals below 50 cents are rounded down", value: "100.49", expected: 100},
+		{name: "50 cents are rounded up", value: "100.5", expected: 101},
+		{name: "decimals above 50 cents are rounded up", value: "100.99", expected: 101},
+		{name: "small decimals are rounded down", value: "0.000000000000000001", expected: 0},
+		{name: "zero is kept", value: "0", expected: 0},
+		{name: "negative decimals below 50 cents are rounded towards zero", value: "-100.49", expected: -100},
+		{name: "negative 50 cents are rounded away from zero", value: "-100.5", expected: -101},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			value, err := sdk.NewDecFromStr(tc.value)
+			if err != nil {
+				t.Fatalf("invalid test value: %s", err)
+			}
+
+			if rounded := RoundToUnit(value); !rounded.Equal(sdk.NewInt(tc.expected)) {
+				t.Errorf("expected %d, got %s", tc.expected, rounded)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Tax represents a tax that can be computed on top of a report
+type Tax byte
+
+func (t Tax) String() string {
+	switch t {
+	case TaxIVCA:
+		return "ivca"
+
+	default:
+		panic(fmt.Errorf("invalid tax value: %d", t))
+	}
+}
+
+const (
+	// TaxIVCA identifies the Italian wealth tax on crypto-assets (Imposta sul Valore delle Cripto-Attività)
+	TaxIVCA Tax = 1
+)
+
+func ParseTax(value string) (Tax, error) {
+	switch strings.ToLower(value) {
+	case "ivca":
+		return TaxIVCA, nil
+	default:
+		return 0, fmt.Errorf("invalid tax value: %s", value)
+	}
+}
+
+// --------------------------------------------------------------------------------------------------------------------
+
+// TaxReport contains the tax that is due on the values of a report
+type TaxReport struct {
+	Tax  string  `yaml:"tax" json:"tax"`
+	Rate sdk.Dec `yaml:"rate" json:"rate"`
+
+	// DaysInPeriod is the number of days of the tax period the holdings are pro-rated against
+	DaysInPeriod int64 `yaml:"daysInPeriod" json:"daysInPeriod"`
+
+	Assets []*AssetTax `yaml:"assets" json:"assets"`
+	Total  sdk.Dec     `yaml:"total" json:"total"`
+}
+
+func NewTaxReport(tax Tax, rate sdk.Dec, daysInPeriod int64, assets []*AssetTax) *TaxReport {
+	total := sdk.ZeroDec()
+	for _, asset := range assets {
+		total = total.Add(asset.Tax)
+	}
+
+	return &TaxReport{
+		Tax:          tax.String(),
+		Rate:         rate,
+		DaysInPeriod: daysInPeriod,
+		Assets:       assets,
+		Total:        total,
+	}
+}
+
+// AssetTax contains the tax that is due on the value of a single asset
+type AssetTax struct {
+	Asset string `yaml:"asset" json:"asset"`
+
+	// Value is the end-of-period value of the asset
+	Value sdk.Dec `yaml:"value" json:"value"`
+
+	// TaxableValue is the value pro-rated by the days the asset has been held and by the ownership share
+	TaxableValue sdk.Dec `yaml:"taxableValue" json:"taxableValue"`
+
+	Tax sdk.Dec `yaml:"tax" json:"tax"`
+}
+
+func NewAssetTax(asset string, value sdk.Dec, taxableValue sdk.Dec, tax sdk.Dec) *AssetTax {
+	return &AssetTax{
+		Asset:        asset,
+		Value:        value,
+		TaxableValue: taxableValue,
+		Tax:          tax,
+	}
+}
+
+// --------------------------------------------------------------------------------------------------------------------
+// CSV Support
+
+type AssetTaxOutput struct {
+	Asset        string `json:"asset" yaml:"asset" csv:"asset"`
+	Value        string `json:"value" yaml:"value" csv:"value"`
+	TaxableValue string `json:"taxable_value" yaml:"taxable_value" csv:"taxable_value"`
+	Tax          string `json:"tax" yaml:"tax" csv:"tax"`
+}
+
+// FormatTax formats the given tax report to be later printed properly, adding a final row containing the total
+func FormatTax(report *TaxReport) []AssetTaxOutput {
+	outputs := make([]AssetTaxOutput, len(report.Assets), len(report.Assets)+1)
+	for i, asset := range report.Assets {
+		outputs[i] = AssetTaxOutput{
+			Asset:        asset.Asset,
+			Value:        asset.Value.String(),
+			TaxableValue: asset.TaxableValue.String(),
+			Tax:          asset.Tax.String(),
+		}
+	}
+
+	return append(outputs, AssetTaxOutput{
+		Asset: "total",
+		Tax:   report.Total.String(),
+	})
+}
@@ -1,6 +1,10 @@
 package types
 
-import "github.com/cosmos/cosmos-sdk/types/bech32"
+import (
+	"sort"
+
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+)
 
 // GetUniqueSupportedAddresses returns the list of all the given addresses that are supported by the
 // provided chain config, removing any duplicated address that might be specified for different chains
@@ -26,5 +30,8 @@ func GetUniqueSupportedAddresses(chainCfg *ChainConfig, addresses []string) ([]s
 		i++
 	}
 
+	// Sort the addresses so that the ordering is deterministic
+	sort.Strings(slice)
+
 	return slice, nil
 }
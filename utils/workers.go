@@ -0,0 +1,27 @@
+package utils
+
+// WorkerPool limits the number of functions that can be executed concurrently
+type WorkerPool struct {
+	slots chan struct{}
+}
+
+// NewWorkerPool returns a new WorkerPool allowing up to size functions to be executed at the same time.
+// If the given size is not positive, a single function will be executed at a time.
+func NewWorkerPool(size int) *WorkerPool {
+	if size <= 0 {
+		size = 1
+	}
+	return &WorkerPool{
+		slots: make(chan struct{}, size),
+	}
+}
+
+// Run executes the given function as soon as a slot is available, blocking until the function returns
+func (p *WorkerPool) Run(fn func()) {
+	p.slots <- struct{}{}
+	defer func() {
+		<-p.slots
+	}()
+
+	fn()
+}